@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"qb-sync/internal/plan"
+	"qb-sync/internal/worker"
+)
+
+// runApply executes a reviewed dry-run plan and reports steps that drifted
+func runApply(args []string) {
+	flags := flag.NewFlagSet("apply", flag.ExitOnError)
+	var (
+		dryRun = flags.Bool("dry-run", false, "Only check the plan against the current state")
+		format = flags.String("format", "", "Plan format: json or yaml (default: from the file extension)")
+		output = outputFlag(flags)
+	)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: qb-sync apply [-dry-run] [-format json|yaml] [-output table|json] <plan file>\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	checkOutput(*output)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	planFormat, err := plan.FormatFor(flags.Arg(0), *format)
+	if err != nil {
+		log.Fatalf("Invalid -format: %v", err)
+	}
+	p, err := plan.ReadFile(flags.Arg(0), planFormat)
+	if err != nil {
+		log.Fatalf("Failed to load plan: %v", err)
+	}
+
+	cfg := loadConfig()
+	if *dryRun {
+		cfg.Monitor.DryRun = true
+	}
+	monitor := newCommandMonitor(cfg)
+	results, err := monitor.ApplyPlan(p)
+	if err != nil {
+		log.Fatalf("Failed to apply plan: %v", err)
+	}
+
+	if *output == outputJSON {
+		if results == nil {
+			results = []worker.StepResult{}
+		}
+		printJSON(results)
+	} else if len(results) > 0 {
+		w := newTable()
+		fmt.Fprintln(w, "HASH\tKIND\tOUTCOME\tSOURCE\tDESTINATION\tDETAILS")
+		for _, result := range results {
+			fmt.Fprintf(w, "%.8s\t%s\t%s\t%s\t%s\t%s\n", result.Hash, result.Kind, result.Outcome, result.Source, result.Destination, result.Detail)
+		}
+		w.Flush()
+	}
+
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Outcome]++
+	}
+	if *output == outputTable {
+		fmt.Printf("%d applied, %d valid, %d drift, %d failed, %d skipped\n",
+			counts[worker.ApplyApplied], counts[worker.ApplyValid], counts[worker.ApplyDrift], counts[worker.ApplyFailed], counts[worker.ApplySkipped])
+	}
+	if counts[worker.ApplyDrift]+counts[worker.ApplyFailed]+counts[worker.ApplySkipped] > 0 {
+		os.Exit(1)
+	}
+}
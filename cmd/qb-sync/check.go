@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/pathmap"
+	"qb-sync/internal/plex"
+	"qb-sync/internal/qbit"
+)
+
+// checkResult is the outcome of one configuration check
+type checkResult struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail"`
+}
+
+// runConfig runs the config subcommands
+func runConfig(args []string) {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintf(os.Stderr, "Usage: qb-sync config check [-output table|json]\n")
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("config check", flag.ExitOnError)
+	output := outputFlag(flags)
+	flags.Parse(args[1:])
+	checkOutput(*output)
+
+	var results []checkResult
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		results = append(results, checkResult{Name: "configuration", Detail: err.Error()})
+	} else {
+		results = append(results, checkResult{Name: "configuration", OK: true, Detail: fmt.Sprintf("category %s -> %s (%s)", cfg.Monitor.Category, cfg.Monitor.DestPath, cfg.Monitor.Operation)})
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		results = append(results, checkQBittorrent(ctx, cfg))
+		results = append(results, checkDirectory("destination", cfg.Monitor.DestPath, cfg.Monitor.DryRun))
+		if cfg.Plex.Enabled {
+			results = append(results, checkPlex(ctx, cfg))
+		}
+	}
+
+	failed := false
+	for _, result := range results {
+		failed = failed || !result.OK
+	}
+
+	if *output == outputJSON {
+		printJSON(results)
+	} else {
+		w := newTable()
+		fmt.Fprintln(w, "CHECK\tSTATUS\tDETAIL")
+		for _, result := range results {
+			status := "ok"
+			if !result.OK {
+				status = "FAILED"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", result.Name, status, result.Detail)
+		}
+		w.Flush()
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// checkQBittorrent logs in to qBittorrent and reports its version
+func checkQBittorrent(ctx context.Context, cfg *config.Config) checkResult {
+	result := checkResult{Name: "qbittorrent"}
+	client, err := qbit.NewClient(&cfg.QB)
+	if err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+	if err := client.Login(ctx); err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+	version, err := client.DetectVersion(ctx)
+	if err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+	result.OK = true
+	result.Detail = fmt.Sprintf("%s at %s", version, cfg.QB.BaseURL)
+	return result
+}
+
+// checkDirectory checks that a directory exists and, unless in dry-run mode, is writable
+func checkDirectory(name, dir string, dryRun bool) checkResult {
+	result := checkResult{Name: name}
+	info, err := os.Stat(dir)
+	if err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+	if !info.IsDir() {
+		result.Detail = dir + " is not a directory"
+		return result
+	}
+	if !dryRun {
+		probe, err := os.CreateTemp(dir, ".qb-sync-check-*")
+		if err != nil {
+			result.Detail = fmt.Sprintf("%s is not writable: %v", dir, err)
+			return result
+		}
+		probe.Close()
+		os.Remove(probe.Name())
+	}
+	result.OK = true
+	result.Detail = dir
+	return result
+}
+
+// checkPlex connects to Plex and checks that a library contains the destination
+func checkPlex(ctx context.Context, cfg *config.Config) checkResult {
+	result := checkResult{Name: "plex"}
+	client, err := plex.NewClient(&cfg.Plex)
+	if err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+	library, _, err := client.FindLibraryByPath(ctx, pathmap.Map(cfg.Plex.PathMappings, cfg.Monitor.DestPath))
+	if err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+	result.OK = true
+	result.Detail = fmt.Sprintf("destination is in library '%s'", library.Title)
+	return result
+}
@@ -9,9 +9,13 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"qb-sync/internal/config"
+	"qb-sync/internal/perms"
+	"qb-sync/internal/plan"
+	"qb-sync/internal/tracker"
 	"qb-sync/internal/worker"
 )
 
@@ -26,18 +30,86 @@ func main() {
 	// Force IPv4 preference for all network operations
 	forceIPv4()
 
+	// The first argument selects a subcommand; flags alone run the monitor
+	command, args := "run", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "run":
+		runMonitor(args)
+	case "restore":
+		runRestore(args)
+	case "trigger":
+		runTrigger(args)
+	case "list":
+		runList(args)
+	case "process":
+		runProcess(args)
+	case "apply":
+		runApply(args)
+	case "plex":
+		runPlex(args)
+	case "config":
+		runConfig(args)
+	case "telegram":
+		runTelegram(args)
+	case "version":
+		printVersion()
+	case "help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", command)
+		usage()
+		os.Exit(2)
+	}
+}
+
+// usage prints the available subcommands
+func usage() {
+	fmt.Fprintf(os.Stderr, `Usage: qb-sync [command] [flags]
+
+Commands:
+  run               Monitor qBittorrent and import completed torrents (default)
+  list              List torrents and their import state
+  process <hash>    Import a single torrent regardless of its category
+  apply <plan>      Execute a reviewed dry-run plan, reporting steps that drifted
+  trigger <hash>    Ask a running qb-sync to process torrents now
+  restore           List or restore files from the recycle bin
+  plex refresh      Refresh directories in Plex
+  plex libraries    List Plex libraries
+  config check      Validate the configuration and test connections
+  telegram test     Send a test message to the allowed Telegram users
+  version           Show version information
+
+Run 'qb-sync <command> -h' for the flags of a command.
+`)
+}
+
+// printVersion prints version information
+func printVersion() {
+	fmt.Printf("qb-sync %s\n", Version)
+	fmt.Printf("Built: %s\n", BuildTime)
+	fmt.Printf("Commit: %s\n", GitCommit)
+}
+
+// runMonitor runs the monitor until a shutdown signal is received
+func runMonitor(args []string) {
 	// Define command line flags
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
 	var (
-		showVersion = flag.Bool("version", false, "Show version information and exit")
-		dryRun      = flag.Bool("dry-run", false, "Run in dry-run mode (no actual file operations or deletions)")
+		showVersion = flags.Bool("version", false, "Show version information and exit")
+		dryRun      = flags.Bool("dry-run", false, "Run in dry-run mode (no actual file operations or deletions)")
+		once        = flags.Bool("once", false, "Process completed torrents once, print a summary and exit (non-zero if a torrent failed)")
+		planFile    = flags.String("plan", "", "Write the dry-run plan to this file after each sync (implies -dry-run)")
+		planFormat  = flags.String("plan-format", "", "Plan format: json or yaml (default: from the file extension)")
 	)
-	flag.Parse()
+	flags.Parse(args)
 
 	// Show version if requested
 	if *showVersion {
-		fmt.Printf("qb-sync %s\n", Version)
-		fmt.Printf("Built: %s\n", BuildTime)
-		fmt.Printf("Commit: %s\n", GitCommit)
+		printVersion()
 		os.Exit(0)
 	}
 
@@ -51,10 +123,23 @@ func main() {
 	if *dryRun {
 		cfg.Monitor.DryRun = true
 	}
+	if *planFile != "" {
+		cfg.Monitor.PlanFile = *planFile
+		cfg.Monitor.DryRun = true
+	}
+	if *planFormat != "" {
+		cfg.Monitor.PlanFormat = *planFormat
+	}
+	if _, err := plan.FormatFor(cfg.Monitor.PlanFile, cfg.Monitor.PlanFormat); err != nil {
+		log.Fatalf("Invalid plan format: %v", err)
+	}
 
 	// Set up logging based on log level
 	setLogLevel(cfg.Monitor.LogLevel)
 
+	// Files and directories are created with the configured umask
+	perms.ApplyUmask(&cfg.Monitor)
+
 	// Log startup information
 	log.Printf("Starting qb-sync %s", Version)
 	log.Printf("Configuration loaded:")
@@ -64,12 +149,28 @@ func main() {
 	log.Printf("  Operation: %s", cfg.Monitor.Operation)
 	log.Printf("  Poll interval: %v", cfg.Monitor.PollInterval)
 	log.Printf("  Dry run: %t", cfg.Monitor.DryRun)
+	if cfg.Monitor.PlanFile != "" {
+		if cfg.Monitor.DryRun {
+			log.Printf("  Plan file: %s", cfg.Monitor.PlanFile)
+		} else {
+			log.Printf("  Plan file: %s (ignored, plans are only written in dry-run mode)", cfg.Monitor.PlanFile)
+		}
+	}
 	if cfg.Plex.Enabled {
 		log.Printf("  Plex URL: %s", cfg.Plex.URL)
 		log.Printf("  Plex enabled: true")
 	} else {
 		log.Printf("  Plex enabled: false")
 	}
+	if cfg.Rclone.Enabled {
+		log.Printf("  rclone RC URL: %s (mount: %s)", cfg.Rclone.URL, cfg.Rclone.MountPath)
+	}
+	if cfg.HTTP.Listen != "" {
+		log.Printf("  HTTP server: %s", cfg.HTTP.Listen)
+	}
+	log.Printf("  Recycle bin: %s (retention: %v)", cfg.Monitor.RecycleDir, cfg.Monitor.RecycleRetention)
+	log.Printf("  Ownership: uid %d, gid %d (-1 keeps the process user), dir mode %o (0 applies the umask), file mode %o (0 keeps the source mode)",
+		cfg.Monitor.UID, cfg.Monitor.GID, cfg.Monitor.DirMode, cfg.Monitor.FileMode)
 
 	// Create and run monitor
 	monitor, err := worker.NewMonitor(cfg)
@@ -81,6 +182,15 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	if *once {
+		go func() {
+			<-sigChan
+			log.Println("Received shutdown signal, cancelling...")
+			monitor.Shutdown()
+		}()
+		os.Exit(runOnce(monitor))
+	}
+
 	// Run monitor in a goroutine
 	go monitor.Run()
 
@@ -89,6 +199,40 @@ func main() {
 	log.Println("Received shutdown signal, exiting...")
 }
 
+// runOnce performs a single sync, prints a summary and returns the exit code
+func runOnce(monitor *worker.Monitor) int {
+	summary, err := monitor.RunOnce()
+
+	if len(summary.Torrents) > 0 {
+		w := newTable()
+		fmt.Fprintln(w, "STATE\tFILES\tTORRENT\tDETAILS")
+		for _, torrent := range summary.Torrents {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", torrent.State, len(torrent.Files), torrent.Name, torrent.Message)
+		}
+		w.Flush()
+	}
+
+	var counts []string
+	for _, state := range []tracker.State{tracker.StateProcessed, tracker.StateFailed, tracker.StateDeferred, tracker.StateSkipped, tracker.StateQuarantined} {
+		if summary.States[state] > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", summary.States[state], state))
+		}
+	}
+	if len(counts) == 0 {
+		counts = append(counts, "nothing to do")
+	}
+	fmt.Printf("%d torrents: %s\n", len(summary.Torrents), strings.Join(counts, ", "))
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Sync failed: %v\n", err)
+		return 1
+	}
+	if summary.Failed() > 0 {
+		return 1
+	}
+	return 0
+}
+
 // setLogLevel configures the global logger based on the specified level
 func setLogLevel(level string) {
 	// For simplicity, we'll just use the standard logger
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/perms"
+	"qb-sync/internal/worker"
+)
+
+// Output formats of commands that print data
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// outputFlag adds the -output flag to a command
+func outputFlag(flags *flag.FlagSet) *string {
+	return flags.String("output", outputTable, "Output format: table or json")
+}
+
+// checkOutput exits if the output format is unknown
+func checkOutput(format string) {
+	if format != outputTable && format != outputJSON {
+		fmt.Fprintf(os.Stderr, "Unknown output format: %s (use table or json)\n", format)
+		os.Exit(2)
+	}
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		log.Fatalf("Failed to encode output: %v", err)
+	}
+}
+
+// newTable returns a writer that aligns tab separated columns on stdout
+func newTable() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+}
+
+// loadConfig loads the configuration or exits
+func loadConfig() *config.Config {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	return cfg
+}
+
+// newCommandMonitor creates a monitor for a one-off command, logging to stderr
+// so stdout only holds the command output. Files it writes get the configured umask.
+func newCommandMonitor(cfg *config.Config) *worker.Monitor {
+	perms.ApplyUmask(&cfg.Monitor)
+	monitor, err := worker.NewMonitor(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create monitor: %v", err)
+	}
+	monitor.SetLogOutput(os.Stderr)
+	return monitor
+}
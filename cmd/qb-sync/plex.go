@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/plex"
+)
+
+// runPlex runs the plex subcommands
+func runPlex(args []string) {
+	if len(args) == 0 {
+		plexUsage()
+		os.Exit(2)
+	}
+	switch args[0] {
+	case "refresh":
+		runPlexRefresh(args[1:])
+	case "libraries":
+		runPlexLibraries(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown plex command: %s\n\n", args[0])
+		plexUsage()
+		os.Exit(2)
+	}
+}
+
+// plexUsage prints the plex subcommands
+func plexUsage() {
+	fmt.Fprintf(os.Stderr, `Usage: qb-sync plex <command>
+
+Commands:
+  refresh <path>...   Refresh local directories in the Plex library containing them
+  libraries           List Plex libraries and their locations
+`)
+}
+
+// newPlexClient creates a Plex client or exits if Plex is not enabled
+func newPlexClient(cfg *config.Config) *plex.Client {
+	if !cfg.Plex.Enabled {
+		log.Fatalf("Plex integration is not enabled (set QB_SYNC_PLEX_ENABLED)")
+	}
+	client, err := plex.NewClient(&cfg.Plex)
+	if err != nil {
+		log.Fatalf("Failed to create Plex client: %v", err)
+	}
+	return client
+}
+
+// runPlexRefresh refreshes directories in Plex
+func runPlexRefresh(args []string) {
+	flags := flag.NewFlagSet("plex refresh", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: qb-sync plex refresh <path>...\n")
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	client := newPlexClient(loadConfig())
+	failed := false
+	for _, path := range flags.Args() {
+		if err := client.RefreshPath(context.Background(), path); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to refresh %s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Refreshed %s\n", path)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// runPlexLibraries lists the Plex libraries
+func runPlexLibraries(args []string) {
+	flags := flag.NewFlagSet("plex libraries", flag.ExitOnError)
+	output := outputFlag(flags)
+	flags.Parse(args)
+	checkOutput(*output)
+
+	client := newPlexClient(loadConfig())
+	libraries, err := client.GetLibraries(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to list Plex libraries: %v", err)
+	}
+
+	if *output == outputJSON {
+		if libraries == nil {
+			libraries = []plex.Library{}
+		}
+		printJSON(libraries)
+		return
+	}
+
+	w := newTable()
+	fmt.Fprintln(w, "KEY\tTYPE\tTITLE\tLOCATIONS")
+	for _, library := range libraries {
+		var locations []string
+		for _, location := range library.Locations {
+			locations = append(locations, location.Path)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", library.Key, library.Type, library.Title, strings.Join(locations, ", "))
+	}
+	w.Flush()
+}
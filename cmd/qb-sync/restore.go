@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"qb-sync/internal/perms"
+	"qb-sync/internal/recycle"
+)
+
+// runRestore lists recycled files or restores them to their original paths
+func runRestore(args []string) {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	var (
+		list  = flags.Bool("list", false, "List recycled files")
+		force = flags.Bool("force", false, "Recycle files that exist at the original path instead of failing")
+	)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: qb-sync restore -list\n       qb-sync restore [-force] <id|original path>...\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if !*list && flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cfg := loadConfig()
+	perms.ApplyUmask(&cfg.Monitor)
+	bin := recycle.New(&cfg.Monitor)
+
+	if *list {
+		entries, err := bin.List()
+		if err != nil {
+			log.Fatalf("Failed to list recycle bin: %v", err)
+		}
+		w := newTable()
+		fmt.Fprintln(w, "ID\tRECYCLED\tSIZE\tREASON\tORIGINAL")
+		for _, entry := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", entry.ID, entry.RecycledAt.Format("2006-01-02 15:04:05"), entry.Size, entry.Reason, entry.Original)
+		}
+		w.Flush()
+		return
+	}
+
+	failed := false
+	for _, key := range flags.Args() {
+		matches, err := bin.Find(key)
+		if err != nil {
+			log.Fatalf("Failed to read recycle bin: %v", err)
+		}
+		if len(matches) == 0 {
+			fmt.Fprintf(os.Stderr, "Not found in recycle bin: %s\n", key)
+			failed = true
+			continue
+		}
+
+		// The most recent entry wins when a path was recycled more than once
+		entry := matches[0]
+		if err := bin.Restore(entry, *force); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to restore %s: %v\n", key, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Restored %s\n", entry.Original)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"qb-sync/internal/telegram"
+)
+
+// runTelegram runs the telegram subcommands
+func runTelegram(args []string) {
+	if len(args) == 0 || args[0] != "test" {
+		fmt.Fprintf(os.Stderr, "Usage: qb-sync telegram test\n")
+		os.Exit(2)
+	}
+
+	cfg := loadConfig()
+	if !cfg.Telegram.Enabled {
+		log.Fatalf("Telegram is not enabled (set QB_SYNC_TELEGRAM_ENABLED)")
+	}
+	bot, err := telegram.NewBot(cfg.Telegram.Token, cfg.Telegram.AllowedUsers, nil, true)
+	if err != nil {
+		log.Fatalf("Failed to create Telegram bot: %v", err)
+	}
+	if err := bot.SendTestMessage(); err != nil {
+		log.Fatalf("Failed to send test message: %v", err)
+	}
+	fmt.Printf("Sent a test message to %d users\n", len(cfg.Telegram.AllowedUsers))
+}
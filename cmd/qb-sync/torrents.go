@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"qb-sync/internal/tracker"
+	"qb-sync/internal/worker"
+)
+
+// runList lists torrents with their import state
+func runList(args []string) {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	var (
+		all    = flags.Bool("all", false, "List torrents of all categories")
+		output = outputFlag(flags)
+	)
+	flags.Parse(args)
+	checkOutput(*output)
+
+	monitor := newCommandMonitor(loadConfig())
+	statuses, err := monitor.ListTorrents(*all)
+	if err != nil {
+		log.Fatalf("Failed to list torrents: %v", err)
+	}
+
+	if *output == outputJSON {
+		if statuses == nil {
+			statuses = []worker.TorrentStatus{}
+		}
+		printJSON(statuses)
+		return
+	}
+
+	w := newTable()
+	fmt.Fprintln(w, "HASH\tSTATE\tPROGRESS\tIMPORT\tCATEGORY\tNAME")
+	for _, status := range statuses {
+		imported := status.Import
+		switch {
+		case status.Error != "":
+			imported = "error: " + status.Error
+		case status.Import == worker.ImportPartial:
+			imported = fmt.Sprintf("%s %d/%d", status.Import, status.Imported, status.Planned)
+		}
+		fmt.Fprintf(w, "%.8s\t%s\t%.0f%%\t%s\t%s\t%s\n", status.Hash, status.State.DisplayName(), status.Progress*100, imported, status.Category, status.Name)
+	}
+	w.Flush()
+}
+
+// runProcess imports a single torrent regardless of its category
+func runProcess(args []string) {
+	flags := flag.NewFlagSet("process", flag.ExitOnError)
+	var (
+		dryRun   = flags.Bool("dry-run", false, "Show what would be done without changing anything")
+		planFile = flags.String("plan", "", "Write the dry-run plan to this file (implies -dry-run)")
+		output   = outputFlag(flags)
+	)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: qb-sync process [-dry-run] [-plan file] [-output table|json] <hash>\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	checkOutput(*output)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cfg := loadConfig()
+	if *dryRun {
+		cfg.Monitor.DryRun = true
+	}
+	if *planFile != "" {
+		cfg.Monitor.PlanFile = *planFile
+		cfg.Monitor.DryRun = true
+	}
+	monitor := newCommandMonitor(cfg)
+	result, err := monitor.ProcessHash(flags.Arg(0))
+	if err != nil && result.Hash == "" {
+		log.Fatalf("Failed to process torrent: %v", err)
+	}
+
+	if *output == outputJSON {
+		printJSON(result)
+	} else {
+		w := newTable()
+		fmt.Fprintln(w, "METHOD\tDECISION\tRESULT\tSOURCE\tDESTINATION")
+		for _, file := range result.Files {
+			outcome := "ok"
+			if !file.Success {
+				outcome = "failed: " + file.Error
+			}
+			source := file.Source
+			if file.Entry != "" {
+				source = fmt.Sprintf("%s [%s]", file.Source, file.Entry)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", file.Method, file.Decision, outcome, source, file.Destination)
+		}
+		w.Flush()
+		fmt.Printf("%s: %s", result.Name, result.State)
+		if result.Message != "" {
+			fmt.Printf(" (%s)", result.Message)
+		}
+		fmt.Println()
+	}
+
+	if result.State != tracker.StateProcessed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// runTrigger asks a running qb-sync to process torrents immediately.
+// It only needs the server URL and token, so it works from qBittorrent's
+// "Run external program on torrent finished" without the full configuration.
+func runTrigger(args []string) {
+	flags := flag.NewFlagSet("trigger", flag.ExitOnError)
+	var (
+		serverURL = flags.String("url", defaultTriggerURL(), "qb-sync HTTP server URL (QB_SYNC_TRIGGER_URL)")
+		token     = flags.String("token", os.Getenv("QB_SYNC_HTTP_TOKEN"), "HTTP token (QB_SYNC_HTTP_TOKEN)")
+		timeout   = flags.Duration("timeout", 10*time.Second, "Request timeout")
+	)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: qb-sync trigger [-url URL] [-token TOKEN] <hash>...\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 || *serverURL == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	client := &http.Client{Timeout: *timeout}
+	failed := false
+	for _, hash := range flags.Args() {
+		if err := trigger(client, *serverURL, *token, hash); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to trigger %s: %v\n", hash, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("Queued %s\n", hash)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// trigger posts a hash to the trigger endpoint
+func trigger(client *http.Client, serverURL, token, hash string) error {
+	body, err := json.Marshal(map[string]string{"hash": hash})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(serverURL, "/")+"/api/v1/trigger", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("server returned %d: %s", resp.StatusCode, apiErr.Error)
+		}
+		return fmt.Errorf("server returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// defaultTriggerURL returns QB_SYNC_TRIGGER_URL, or the local address of QB_SYNC_HTTP_LISTEN
+func defaultTriggerURL() string {
+	if triggerURL := os.Getenv("QB_SYNC_TRIGGER_URL"); triggerURL != "" {
+		return triggerURL
+	}
+	host, port, err := net.SplitHostPort(os.Getenv("QB_SYNC_HTTP_LISTEN"))
+	if err != nil {
+		return ""
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
@@ -2,4 +2,8 @@ module qb-sync
 
 go 1.21
 
-require github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1 // indirect
+require (
+	github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
+	github.com/nwaples/rardecode v1.1.3
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,39 @@
+package api
+
+import "net/http"
+
+// ConfigResponse describes where and how torrents are imported. Secrets are never included.
+type ConfigResponse struct {
+	Category        string `json:"category"`
+	Destination     string `json:"destination"`
+	Operation       string `json:"operation"`
+	ConflictPolicy  string `json:"conflict_policy"`
+	MovieTemplate   string `json:"movie_template,omitempty"`
+	EpisodeTemplate string `json:"episode_template,omitempty"`
+	ExtractArchives bool   `json:"extract_archives"`
+	UpgradeMode     bool   `json:"upgrade_mode"`
+	DeleteTorrent   bool   `json:"delete_torrent"`
+	DryRun          bool   `json:"dry_run"`
+	PlexEnabled     bool   `json:"plex_enabled"`
+}
+
+// handleConfig reports the import settings
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	monitor := s.cfg.Monitor
+	writeJSON(w, http.StatusOK, ConfigResponse{
+		Category:        monitor.Category,
+		Destination:     monitor.DestPath,
+		Operation:       monitor.Operation,
+		ConflictPolicy:  monitor.ConflictPolicy,
+		MovieTemplate:   monitor.MovieTemplate,
+		EpisodeTemplate: monitor.EpisodeTemplate,
+		ExtractArchives: monitor.ExtractArchives,
+		UpgradeMode:     monitor.UpgradeMode,
+		DeleteTorrent:   monitor.DeleteTorrent,
+		DryRun:          monitor.DryRun,
+		PlexEnabled:     s.cfg.Plex.Enabled,
+	})
+}
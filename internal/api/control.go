@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// StatusResponse describes what the monitor is doing
+type StatusResponse struct {
+	Paused       bool           `json:"paused"`
+	DryRun       bool           `json:"dry_run"`
+	Category     string         `json:"category"`
+	Destination  string         `json:"destination"`
+	PollInterval string         `json:"poll_interval"` // interval the monitor loop currently uses
+	LastSync     *time.Time     `json:"last_sync,omitempty"`
+	Torrents     map[string]int `json:"torrents"` // tracked torrents per state
+}
+
+// PlexRefreshRequest is the optional JSON body of the Plex refresh endpoint
+type PlexRefreshRequest struct {
+	Path string `json:"path"` // local directory to refresh, defaults to the destination
+}
+
+// handleStatus reports the monitor state
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.status())
+}
+
+// status builds the status response
+func (s *Server) status() StatusResponse {
+	status := StatusResponse{
+		Paused:       s.monitor.Paused(),
+		DryRun:       s.cfg.Monitor.DryRun,
+		Category:     s.cfg.Monitor.Category,
+		Destination:  s.cfg.Monitor.DestPath,
+		PollInterval: s.monitor.PollInterval().String(),
+		Torrents:     make(map[string]int),
+	}
+	if lastSync := s.monitor.LastSync(); !lastSync.IsZero() {
+		status.LastSync = &lastSync
+	}
+	for _, torrent := range s.monitor.Torrents() {
+		status.Torrents[string(torrent.State)]++
+	}
+	return status
+}
+
+// handlePause pauses the monitor loop
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	s.monitor.Pause()
+	writeJSON(w, http.StatusOK, s.status())
+}
+
+// handleResume resumes the monitor loop
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	s.monitor.Resume()
+	writeJSON(w, http.StatusOK, s.status())
+}
+
+// handlePlexRefresh refreshes a directory in Plex
+func (s *Server) handlePlexRefresh(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	if !s.cfg.Plex.Enabled {
+		writeError(w, http.StatusConflict, "Plex integration is not enabled")
+		return
+	}
+
+	var body PlexRefreshRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+	}
+	if err := s.monitor.RefreshPlex(r.Context(), body.Path); err != nil {
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "refreshed"})
+}
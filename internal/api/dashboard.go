@@ -0,0 +1,21 @@
+package api
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// webFiles holds the dashboard. It calls the API with a token the user enters in the browser.
+//
+//go:embed web
+var webFiles embed.FS
+
+// dashboardHandler serves the dashboard files. They contain no data, so they need no token.
+func dashboardHandler() http.Handler {
+	files, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServer(http.FS(files))
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec describes the HTTP API
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// handleOpenAPI serves the OpenAPI description. It needs no token so clients can discover the API.
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/tracker"
+)
+
+// Monitor interface for the monitor operations exposed over HTTP
+type Monitor interface {
+	Enqueue(hash string)
+	Reprocess(hash string)
+	Skip(hash string)
+	Torrents() []tracker.Torrent
+	Torrent(hash string) (tracker.Torrent, bool)
+	Pause()
+	Resume()
+	Paused() bool
+	LastSync() time.Time
+	PollInterval() time.Duration
+	RefreshPlex(ctx context.Context, dirPath string) error
+}
+
+// Server serves the qb-sync HTTP API
+type Server struct {
+	cfg     *config.Config
+	monitor Monitor
+	mux     *http.ServeMux
+}
+
+// NewServer creates a new HTTP server
+func NewServer(cfg *config.Config, monitor Monitor) *Server {
+	s := &Server{
+		cfg:     cfg,
+		monitor: monitor,
+		mux:     http.NewServeMux(),
+	}
+	s.mux.Handle("/", dashboardHandler())
+	s.mux.HandleFunc("/api/v1/openapi.json", s.handleOpenAPI)
+	s.mux.HandleFunc("/api/v1/status", s.authenticate(s.handleStatus))
+	s.mux.HandleFunc("/api/v1/config", s.authenticate(s.handleConfig))
+	s.mux.HandleFunc("/api/v1/pause", s.authenticate(s.handlePause))
+	s.mux.HandleFunc("/api/v1/resume", s.authenticate(s.handleResume))
+	s.mux.HandleFunc("/api/v1/plex/refresh", s.authenticate(s.handlePlexRefresh))
+	s.mux.HandleFunc("/api/v1/torrents", s.authenticate(s.handleTorrents))
+	s.mux.HandleFunc("/api/v1/torrents/", s.authenticate(s.handleTorrent))
+	s.mux.HandleFunc("/api/v1/trigger", s.authenticate(s.handleTrigger))
+	s.mux.HandleFunc("/api/v1/trigger/", s.authenticate(s.handleTrigger))
+	return s
+}
+
+// Start serves requests until ctx is cancelled
+func (s *Server) Start(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              s.cfg.HTTP.Listen,
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("http: listening on %s", s.cfg.HTTP.Listen)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("http server failed: %w", err)
+	}
+	return nil
+}
+
+// authenticate rejects requests without the configured token. The token is accepted as a
+// bearer token, in the X-Api-Key header or, for callers that can only be given a URL, as
+// the token query parameter.
+func (s *Server) authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Api-Key")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.HTTP.Token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid or missing token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// allowMethod rejects requests using another method than the given one
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	return false
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error message as a JSON response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
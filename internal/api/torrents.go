@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"qb-sync/internal/tracker"
+)
+
+// TorrentSummary is a tracked torrent with file counts, listed without its file results by default
+type TorrentSummary struct {
+	tracker.Torrent
+	FileCount   int `json:"file_count"`
+	FailedFiles int `json:"failed_files"`
+}
+
+// handleTorrents lists tracked torrents, optionally filtered by the state query parameter.
+// File results are included with files=true.
+func (s *Server) handleTorrents(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	state := tracker.State(r.URL.Query().Get("state"))
+	withFiles := r.URL.Query().Get("files") == "true"
+	summaries := []TorrentSummary{}
+	for _, torrent := range s.monitor.Torrents() {
+		if state != "" && torrent.State != state {
+			continue
+		}
+		summary := TorrentSummary{Torrent: torrent, FileCount: len(torrent.Files)}
+		for _, file := range torrent.Files {
+			if !file.Success {
+				summary.FailedFiles++
+			}
+		}
+		if !withFiles {
+			summary.Files = nil
+		}
+		summaries = append(summaries, summary)
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// handleTorrent serves /api/v1/torrents/<hash>[/files|/reprocess|/skip]
+func (s *Server) handleTorrent(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/torrents/"), "/")
+	hash, action, _ := strings.Cut(rest, "/")
+	hash, ok := parseHash(w, hash)
+	if !ok {
+		return
+	}
+
+	switch action {
+	case "":
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		torrent, found := s.monitor.Torrent(hash)
+		if !found {
+			writeError(w, http.StatusNotFound, "torrent is not tracked")
+			return
+		}
+		writeJSON(w, http.StatusOK, torrent)
+	case "files":
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		torrent, found := s.monitor.Torrent(hash)
+		if !found {
+			writeError(w, http.StatusNotFound, "torrent is not tracked")
+			return
+		}
+		results := torrent.Files
+		if results == nil {
+			results = []tracker.FileResult{}
+		}
+		writeJSON(w, http.StatusOK, results)
+	case "reprocess":
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+		s.monitor.Reprocess(hash)
+		writeJSON(w, http.StatusAccepted, HashResponse{Hash: hash, Status: "queued"})
+	case "skip":
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+		s.monitor.Skip(hash)
+		writeJSON(w, http.StatusOK, HashResponse{Hash: hash, Status: string(tracker.StateSkipped)})
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
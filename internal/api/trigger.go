@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// hashPattern matches v1 (SHA-1) and v2 (SHA-256) info hashes
+var hashPattern = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// TriggerRequest is the JSON body accepted by the trigger endpoint
+type TriggerRequest struct {
+	Hash string `json:"hash"`
+}
+
+// HashResponse is returned by endpoints acting on a torrent
+type HashResponse struct {
+	Hash   string `json:"hash"`
+	Status string `json:"status"`
+}
+
+// handleTrigger queues a torrent for immediate processing. The hash is taken from the
+// path (/api/v1/trigger/<hash>), the hash query or form parameter, or a JSON body.
+func (s *Server) handleTrigger(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	hash := strings.TrimPrefix(r.URL.Path, "/api/v1/trigger")
+	hash = strings.Trim(hash, "/")
+	if hash == "" && strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var body TriggerRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON body")
+			return
+		}
+		hash = body.Hash
+	}
+	if hash == "" {
+		hash = r.FormValue("hash")
+	}
+
+	hash, ok := parseHash(w, hash)
+	if !ok {
+		return
+	}
+
+	log.Printf("http: torrent %s queued by %s", hash, r.RemoteAddr)
+	s.monitor.Enqueue(hash)
+	writeJSON(w, http.StatusAccepted, HashResponse{Hash: hash, Status: "queued"})
+}
+
+// parseHash normalizes an info hash, writing an error response if it is invalid
+func parseHash(w http.ResponseWriter, hash string) (string, bool) {
+	hash = strings.ToLower(strings.TrimSpace(hash))
+	if !hashPattern.MatchString(hash) {
+		writeError(w, http.StatusBadRequest, "hash must be a 40 or 64 character hex info hash")
+		return "", false
+	}
+	return hash, true
+}
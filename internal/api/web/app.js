@@ -0,0 +1,244 @@
+// qb-sync dashboard: renders the REST API and calls it with the token kept in localStorage.
+"use strict";
+
+const tokenKey = "qb-sync-token";
+const refreshInterval = 5000;
+let refreshTimer = null;
+
+// api calls an API endpoint and returns the decoded JSON response
+async function api(method, path, body) {
+  const options = {
+    method: method,
+    headers: { "Authorization": "Bearer " + localStorage.getItem(tokenKey) },
+  };
+  if (body !== undefined) {
+    options.headers["Content-Type"] = "application/json";
+    options.body = JSON.stringify(body);
+  }
+  const response = await fetch("api/v1/" + path, options);
+  const data = await response.json().catch(() => ({}));
+  if (response.status === 401) {
+    showLogin("Invalid token");
+    throw new Error("unauthorized");
+  }
+  if (!response.ok) {
+    throw new Error(data.error || response.statusText);
+  }
+  return data;
+}
+
+// el creates an element with text content and optional children
+function el(tag, text, ...children) {
+  const node = document.createElement(tag);
+  if (text !== undefined && text !== null) {
+    node.textContent = text;
+  }
+  children.forEach((child) => node.appendChild(child));
+  return node;
+}
+
+// stateBadge renders a torrent state
+function stateBadge(state) {
+  const badge = el("span", state);
+  badge.className = "state state-" + state;
+  return badge;
+}
+
+// formatTime renders a timestamp in local time
+function formatTime(value) {
+  return value ? new Date(value).toLocaleString() : "";
+}
+
+// actionButton creates a button that runs an API call and refreshes the dashboard
+function actionButton(label, method, path, body) {
+  const button = el("button", label);
+  button.addEventListener("click", async () => {
+    button.disabled = true;
+    try {
+      await api(method, path, body);
+      showMessage(label + " requested");
+    } catch (err) {
+      showMessage(label + " failed: " + err.message);
+    }
+    refresh();
+  });
+  return button;
+}
+
+// renderTable fills a table with a header and one row per item
+function renderTable(table, headers, items, row) {
+  table.replaceChildren(el("tr", null, ...headers.map((header) => el("th", header))));
+  if (items.length === 0) {
+    const empty = el("td", "Nothing here");
+    empty.colSpan = headers.length;
+    empty.className = "empty";
+    table.appendChild(el("tr", null, empty));
+    return;
+  }
+  items.forEach((item) => {
+    table.appendChild(el("tr", null, ...row(item).map((cell) => {
+      return cell instanceof Node ? el("td", null, cell) : el("td", cell);
+    })));
+  });
+}
+
+// torrentActions returns the retry and skip buttons of a torrent. Quarantined torrents
+// are only retried once the quarantine tag is removed in qBittorrent, so they get no retry.
+function torrentActions(torrent) {
+  const actions = el("div");
+  actions.className = "actions";
+  if (torrent.state !== "quarantined") {
+    actions.appendChild(actionButton("Retry", "POST", "torrents/" + torrent.hash + "/reprocess"));
+  }
+  if (torrent.state !== "skipped") {
+    actions.appendChild(actionButton("Skip", "POST", "torrents/" + torrent.hash + "/skip"));
+  }
+  return actions;
+}
+
+// failureDetails lists the message and failed files of a torrent
+function failureDetails(torrent) {
+  const details = el("div", torrent.message || "");
+  const failed = (torrent.files || []).filter((file) => !file.success);
+  if (failed.length > 0) {
+    details.appendChild(el("ul", null, ...failed.map((file) => el("li", file.source + ": " + file.error))));
+    details.lastChild.className = "files";
+  }
+  return details;
+}
+
+// render draws all sections from the API responses
+function render(status, torrents, config) {
+  const parts = [status.paused ? "Paused" : "Running"];
+  if (status.dry_run) {
+    parts.push("dry run");
+  }
+  if (status.last_sync) {
+    parts.push("last sync " + formatTime(status.last_sync));
+  }
+  document.getElementById("status").textContent = parts.join(" · ");
+  document.getElementById("pause").hidden = status.paused;
+  document.getElementById("resume").hidden = !status.paused;
+  document.getElementById("plex-refresh").hidden = !config.plex_enabled;
+
+  const inState = (...states) => torrents.filter((torrent) => states.includes(torrent.state));
+
+  renderTable(document.getElementById("queue"), ["Torrent", "State", "Details", "Updated", ""],
+    inState("pending", "processing", "deferred"),
+    (t) => [t.name || t.hash, stateBadge(t.state), t.message || "", formatTime(t.updated_at), torrentActions(t)]);
+
+  renderTable(document.getElementById("failures"), ["Torrent", "Error", "Attempts", "Updated", ""],
+    inState("failed"),
+    (t) => [t.name || t.hash, failureDetails(t), String(t.attempts), formatTime(t.updated_at), torrentActions(t)]);
+
+  renderTable(document.getElementById("held"), ["Torrent", "State", "Reason", "Updated", ""],
+    inState("quarantined", "skipped"),
+    (t) => [t.name || t.hash, stateBadge(t.state), t.message || "", formatTime(t.updated_at), torrentActions(t)]);
+
+  const recent = inState("processed")
+    .sort((a, b) => new Date(b.processed_at) - new Date(a.processed_at))
+    .slice(0, 25);
+  renderTable(document.getElementById("recent"), ["Torrent", "Files", "Imported", ""],
+    recent,
+    (t) => [t.name || t.hash, String(t.files.filter((f) => f.success && f.decision !== "recycled").length), formatTime(t.processed_at),
+      config.plex_enabled ? plexButton(t) : ""]);
+
+  const settings = [
+    ["Route", config.category + " → " + config.destination],
+    ["Operation", config.operation + (config.extract_archives ? ", extract archives" : "")],
+    ["Conflict policy", config.conflict_policy + (config.upgrade_mode ? ", upgrades enabled" : "")],
+    ["Movie template", config.movie_template || "(keep names)"],
+    ["Episode template", config.episode_template || "(keep names)"],
+    ["Delete torrents", config.delete_torrent ? "yes" : "no"],
+    ["Plex", config.plex_enabled ? "enabled" : "disabled"],
+  ];
+  document.getElementById("config").replaceChildren(...settings.flatMap(([name, value]) => [el("dt", name), el("dd", value)]));
+}
+
+// plexButton refreshes the directories a torrent was imported to
+function plexButton(torrent) {
+  const dirs = [...new Set(torrent.files
+    .filter((file) => file.success && file.decision !== "recycled")
+    .map((file) => file.destination.substring(0, file.destination.lastIndexOf("/"))))];
+  const button = el("button", "Refresh Plex");
+  button.addEventListener("click", async () => {
+    button.disabled = true;
+    try {
+      for (const dir of dirs) {
+        await api("POST", "plex/refresh", { path: dir });
+      }
+      showMessage("Plex refreshed for " + torrent.name);
+    } catch (err) {
+      showMessage("Plex refresh failed: " + err.message);
+    }
+    button.disabled = false;
+  });
+  button.disabled = dirs.length === 0;
+  return button;
+}
+
+// refresh reloads the dashboard data
+async function refresh() {
+  clearTimeout(refreshTimer);
+  try {
+    const [status, torrents, config] = await Promise.all([api("GET", "status"), api("GET", "torrents?files=true"), api("GET", "config")]);
+    torrents.forEach((torrent) => { torrent.files = torrent.files || []; });
+    render(status, torrents, config);
+  } catch (err) {
+    if (err.message === "unauthorized") {
+      return;
+    }
+    showMessage("Failed to load: " + err.message);
+  }
+  refreshTimer = setTimeout(refresh, refreshInterval);
+}
+
+// showMessage shows a notice above the dashboard
+function showMessage(text) {
+  const message = document.getElementById("message");
+  message.textContent = text;
+  message.hidden = false;
+}
+
+// showLogin asks for the API token
+function showLogin(error) {
+  clearTimeout(refreshTimer);
+  document.getElementById("dashboard").hidden = true;
+  document.getElementById("login").hidden = false;
+  document.getElementById("login-error").textContent = error || "";
+}
+
+// showDashboard starts rendering the dashboard
+function showDashboard() {
+  document.getElementById("login").hidden = true;
+  document.getElementById("dashboard").hidden = false;
+  refresh();
+}
+
+document.getElementById("login").addEventListener("submit", (event) => {
+  event.preventDefault();
+  localStorage.setItem(tokenKey, document.getElementById("token").value);
+  showDashboard();
+});
+
+document.getElementById("logout").addEventListener("click", () => {
+  localStorage.removeItem(tokenKey);
+  showLogin();
+});
+
+document.getElementById("pause").addEventListener("click", () => api("POST", "pause").then(refresh));
+document.getElementById("resume").addEventListener("click", () => api("POST", "resume").then(refresh));
+document.getElementById("plex-refresh").addEventListener("click", async () => {
+  try {
+    await api("POST", "plex/refresh");
+    showMessage("Plex refresh requested for the destination");
+  } catch (err) {
+    showMessage("Plex refresh failed: " + err.message);
+  }
+});
+
+if (localStorage.getItem(tokenKey)) {
+  showDashboard();
+} else {
+  showLogin();
+}
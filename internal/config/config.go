@@ -3,17 +3,26 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
+
+	"qb-sync/internal/pathmap"
+	"qb-sync/internal/release"
 )
 
 // Config represents the application configuration
 type Config struct {
 	QB       QBConfig
 	Monitor  MonitorConfig
+	Filter   FilterConfig
 	Plex     PlexConfig
+	Rclone   RcloneConfig
 	Telegram TelegramConfig
+	HTTP     HTTPConfig
 }
 
 // QBConfig contains qBittorrent connection settings
@@ -36,13 +45,69 @@ type MonitorConfig struct {
 	PreserveSubfolder   bool
 	DryRun             bool
 	LogLevel            string
+	BrokenAction        string        // none|recheck|reannounce
+	BrokenGracePeriod   time.Duration // how long a torrent may stay broken before it is quarantined
+	BrokenMaxAttempts   int           // recovery cycles after which a torrent that breaks again is quarantined
+	QuarantineTag       string
+	ReadyTimeout        time.Duration // how long to wait for source files to appear on the mount
+	ReadyInterval       time.Duration
+	PathMappings        []pathmap.Rule // qBittorrent paths -> local paths, first match wins
+	MovieTemplate       string         // Go template for movie destination paths
+	EpisodeTemplate     string         // Go template for episode destination paths
+	ExtractArchives     bool           // extract RAR/ZIP sets instead of linking their volumes
+	RenameSubtitles     bool           // rename subtitles to Name.lang.forced.ext next to their video
+	PlaceExtras         bool           // move featurettes, trailers etc. into Plex extras folders
+	ConflictPolicy      string         // skip|overwrite|keep-both|replace-if-larger|replace-if-better|fail
+	UpgradeMode         bool           // replace existing media of the same title/episode when the new release ranks higher
+	QualityProfile      release.QualityProfile
+	RecycleDir          string        // replaced files are moved here instead of being deleted
+	RecycleRetention    time.Duration // recycled files older than this are purged, 0 keeps them forever
+	OrphanInterval      time.Duration // how often the destination is scanned for orphans, 0 disables the scan
+	OrphanRemove        bool          // move orphans to the recycle bin instead of only reporting them
+	OrphanHardlinks     bool          // treat imported files with a link count of 1 whose torrent is gone as orphans
+	StateDir            string        // state that outlives a run, such as the record of imported files
+	DiskReserve         int64         // bytes kept free on destination filesystems when copying or extracting
+	UID                 int           // owner of created files and directories, -1 keeps the process user
+	GID                 int           // group of created files and directories, -1 keeps the process group
+	FileMode            os.FileMode   // mode of copied and extracted files, 0 keeps the source mode
+	DirMode             os.FileMode   // mode of created directories, 0 applies the umask to 0777
+	Umask               int           // process umask, -1 keeps the inherited umask
+	Watch               bool          // sync on filesystem events in the save path in addition to polling
+	WatchPath           string        // local save path to watch, defaults to qBittorrent's default save path
+	WatchDebounce       time.Duration // quiet period after the last event before syncing
+	WatchPollInterval   time.Duration // safety poll interval while watching
+	PlanFile            string        // file the dry-run plan is written to after each sync
+	PlanFormat          string        // json|yaml, empty picks the format from the plan file extension
+}
+
+// FilterConfig contains rules deciding which torrent files are imported
+type FilterConfig struct {
+	IncludeExtensions []string // if set, only files with these extensions (or matching other include rules) are kept
+	ExcludeExtensions []string
+	IncludeGlobs      []string // matched against the file's relative path and base name
+	ExcludeGlobs      []string
+	IncludeRegex      string
+	ExcludeRegex      string
+	MinSize           int64   // files smaller than this many bytes are skipped
+	SkipSamples       bool    // skip sample videos
+	SampleRatio       float64 // videos named "sample" smaller than this fraction of the largest video are samples
 }
 
 // PlexConfig contains Plex Media Server connection settings
 type PlexConfig struct {
-	URL     string
-	Token   string
-	Enabled bool
+	URL          string
+	Token        string
+	Enabled      bool
+	PathMappings []pathmap.Rule // local paths -> Plex paths, first match wins
+}
+
+// RcloneConfig contains rclone remote control settings
+type RcloneConfig struct {
+	URL       string
+	Username  string
+	Password  string
+	MountPath string // local path where the rclone remote is mounted
+	Enabled   bool
 }
 
 // TelegramConfig contains Telegram Bot settings
@@ -53,11 +118,20 @@ type TelegramConfig struct {
 }
 
 
+// HTTPConfig contains settings of the HTTP API server
+type HTTPConfig struct {
+	Listen string // address to listen on, empty disables the server
+	Token  string // token required on every request
+}
+
 // LoadConfig loads configuration from environment variables only
 func LoadConfig() (*Config, error) {
 	// Initialize empty config
 	var cfg Config
 
+	// 0 is a valid owner and umask, so "unchanged" needs its own value
+	cfg.Monitor.UID, cfg.Monitor.GID, cfg.Monitor.Umask = -1, -1, -1
+
 	// Apply environment variable overrides for QBConfig
 	if baseURL := os.Getenv("QB_SYNC_BASE_URL"); baseURL != "" {
 		cfg.QB.BaseURL = baseURL
@@ -102,9 +176,192 @@ func LoadConfig() (*Config, error) {
 	if dryRun := os.Getenv("QB_SYNC_DRY_RUN"); dryRun != "" {
 		cfg.Monitor.DryRun = dryRun == "true" || dryRun == "1"
 	}
+	if planFile := os.Getenv("QB_SYNC_PLAN_FILE"); planFile != "" {
+		cfg.Monitor.PlanFile = planFile
+	}
+	if planFormat := os.Getenv("QB_SYNC_PLAN_FORMAT"); planFormat != "" {
+		cfg.Monitor.PlanFormat = planFormat
+	}
 	if logLevel := os.Getenv("QB_SYNC_LOG_LEVEL"); logLevel != "" {
 		cfg.Monitor.LogLevel = logLevel
 	}
+	if conflictPolicy := os.Getenv("QB_SYNC_CONFLICT_POLICY"); conflictPolicy != "" {
+		cfg.Monitor.ConflictPolicy = conflictPolicy
+	}
+	if brokenAction := os.Getenv("QB_SYNC_BROKEN_ACTION"); brokenAction != "" {
+		cfg.Monitor.BrokenAction = brokenAction
+	}
+	if brokenGracePeriod := os.Getenv("QB_SYNC_BROKEN_GRACE_PERIOD"); brokenGracePeriod != "" {
+		if duration, err := time.ParseDuration(brokenGracePeriod); err == nil {
+			cfg.Monitor.BrokenGracePeriod = duration
+		}
+	}
+	if brokenMaxAttempts := os.Getenv("QB_SYNC_BROKEN_MAX_ATTEMPTS"); brokenMaxAttempts != "" {
+		attempts, err := strconv.Atoi(brokenMaxAttempts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid QB_SYNC_BROKEN_MAX_ATTEMPTS: %w", err)
+		}
+		cfg.Monitor.BrokenMaxAttempts = attempts
+	}
+	if quarantineTag := os.Getenv("QB_SYNC_QUARANTINE_TAG"); quarantineTag != "" {
+		cfg.Monitor.QuarantineTag = quarantineTag
+	}
+	if readyTimeout := os.Getenv("QB_SYNC_READY_TIMEOUT"); readyTimeout != "" {
+		if duration, err := time.ParseDuration(readyTimeout); err == nil {
+			cfg.Monitor.ReadyTimeout = duration
+		}
+	}
+	if readyInterval := os.Getenv("QB_SYNC_READY_INTERVAL"); readyInterval != "" {
+		if duration, err := time.ParseDuration(readyInterval); err == nil {
+			cfg.Monitor.ReadyInterval = duration
+		}
+	}
+	if extractArchives := os.Getenv("QB_SYNC_EXTRACT_ARCHIVES"); extractArchives != "" {
+		cfg.Monitor.ExtractArchives = extractArchives == "true" || extractArchives == "1"
+	}
+	if renameSubtitles := os.Getenv("QB_SYNC_RENAME_SUBTITLES"); renameSubtitles != "" {
+		cfg.Monitor.RenameSubtitles = renameSubtitles == "true" || renameSubtitles == "1"
+	}
+	if placeExtras := os.Getenv("QB_SYNC_PLACE_EXTRAS"); placeExtras != "" {
+		cfg.Monitor.PlaceExtras = placeExtras == "true" || placeExtras == "1"
+	}
+	if upgradeMode := os.Getenv("QB_SYNC_UPGRADE_MODE"); upgradeMode != "" {
+		cfg.Monitor.UpgradeMode = upgradeMode == "true" || upgradeMode == "1"
+	}
+	if resolutions := os.Getenv("QB_SYNC_QUALITY_RESOLUTIONS"); resolutions != "" {
+		cfg.Monitor.QualityProfile.Resolutions = parseList(resolutions)
+	}
+	if sources := os.Getenv("QB_SYNC_QUALITY_SOURCES"); sources != "" {
+		cfg.Monitor.QualityProfile.Sources = parseList(sources)
+	}
+	if recycleDir := os.Getenv("QB_SYNC_RECYCLE_DIR"); recycleDir != "" {
+		cfg.Monitor.RecycleDir = recycleDir
+	}
+	if stateDir := os.Getenv("QB_SYNC_STATE_DIR"); stateDir != "" {
+		cfg.Monitor.StateDir = stateDir
+	}
+	if watch := os.Getenv("QB_SYNC_WATCH"); watch != "" {
+		cfg.Monitor.Watch = watch == "true" || watch == "1"
+	}
+	if watchPath := os.Getenv("QB_SYNC_WATCH_PATH"); watchPath != "" {
+		cfg.Monitor.WatchPath = watchPath
+	}
+	if watchDebounce := os.Getenv("QB_SYNC_WATCH_DEBOUNCE"); watchDebounce != "" {
+		if duration, err := time.ParseDuration(watchDebounce); err == nil {
+			cfg.Monitor.WatchDebounce = duration
+		}
+	}
+	if watchPollInterval := os.Getenv("QB_SYNC_WATCH_POLL_INTERVAL"); watchPollInterval != "" {
+		if duration, err := time.ParseDuration(watchPollInterval); err == nil {
+			cfg.Monitor.WatchPollInterval = duration
+		}
+	}
+	if uid := os.Getenv("QB_SYNC_UID"); uid != "" {
+		id, err := strconv.Atoi(uid)
+		if err != nil {
+			return nil, fmt.Errorf("invalid QB_SYNC_UID: %w", err)
+		}
+		cfg.Monitor.UID = id
+	}
+	if gid := os.Getenv("QB_SYNC_GID"); gid != "" {
+		id, err := strconv.Atoi(gid)
+		if err != nil {
+			return nil, fmt.Errorf("invalid QB_SYNC_GID: %w", err)
+		}
+		cfg.Monitor.GID = id
+	}
+	if fileMode := os.Getenv("QB_SYNC_FILE_MODE"); fileMode != "" {
+		mode, err := strconv.ParseUint(fileMode, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid QB_SYNC_FILE_MODE: %w", err)
+		}
+		cfg.Monitor.FileMode = os.FileMode(mode)
+	}
+	if dirMode := os.Getenv("QB_SYNC_DIR_MODE"); dirMode != "" {
+		mode, err := strconv.ParseUint(dirMode, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid QB_SYNC_DIR_MODE: %w", err)
+		}
+		cfg.Monitor.DirMode = os.FileMode(mode)
+	}
+	if umask := os.Getenv("QB_SYNC_UMASK"); umask != "" {
+		mask, err := strconv.ParseUint(umask, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid QB_SYNC_UMASK: %w", err)
+		}
+		cfg.Monitor.Umask = int(mask)
+	}
+	if diskReserve := os.Getenv("QB_SYNC_DISK_RESERVE"); diskReserve != "" {
+		size, err := ParseSize(diskReserve)
+		if err != nil {
+			return nil, fmt.Errorf("invalid QB_SYNC_DISK_RESERVE: %w", err)
+		}
+		cfg.Monitor.DiskReserve = size
+	}
+	if orphanInterval := os.Getenv("QB_SYNC_ORPHAN_INTERVAL"); orphanInterval != "" {
+		if duration, err := time.ParseDuration(orphanInterval); err == nil {
+			cfg.Monitor.OrphanInterval = duration
+		}
+	}
+	if orphanRemove := os.Getenv("QB_SYNC_ORPHAN_REMOVE"); orphanRemove != "" {
+		cfg.Monitor.OrphanRemove = orphanRemove == "true" || orphanRemove == "1"
+	}
+	if orphanHardlinks := os.Getenv("QB_SYNC_ORPHAN_HARDLINKS"); orphanHardlinks != "" {
+		cfg.Monitor.OrphanHardlinks = orphanHardlinks == "true" || orphanHardlinks == "1"
+	}
+	if recycleRetention := os.Getenv("QB_SYNC_RECYCLE_RETENTION"); recycleRetention != "" {
+		if duration, err := time.ParseDuration(recycleRetention); err == nil {
+			cfg.Monitor.RecycleRetention = duration
+		}
+	}
+	if movieTemplate := os.Getenv("QB_SYNC_MOVIE_TEMPLATE"); movieTemplate != "" {
+		cfg.Monitor.MovieTemplate = movieTemplate
+	}
+	if episodeTemplate := os.Getenv("QB_SYNC_EPISODE_TEMPLATE"); episodeTemplate != "" {
+		cfg.Monitor.EpisodeTemplate = episodeTemplate
+	}
+	if pathMappings := os.Getenv("QB_SYNC_PATH_MAPPINGS"); pathMappings != "" {
+		rules, err := pathmap.Parse(pathMappings)
+		if err != nil {
+			return nil, fmt.Errorf("invalid QB_SYNC_PATH_MAPPINGS: %w", err)
+		}
+		cfg.Monitor.PathMappings = rules
+	}
+
+	// Apply environment variable overrides for FilterConfig
+	if includeExtensions := os.Getenv("QB_SYNC_INCLUDE_EXTENSIONS"); includeExtensions != "" {
+		cfg.Filter.IncludeExtensions = parseExtensions(includeExtensions)
+	}
+	if excludeExtensions := os.Getenv("QB_SYNC_EXCLUDE_EXTENSIONS"); excludeExtensions != "" {
+		cfg.Filter.ExcludeExtensions = parseExtensions(excludeExtensions)
+	}
+	if includeGlobs := os.Getenv("QB_SYNC_INCLUDE_GLOBS"); includeGlobs != "" {
+		cfg.Filter.IncludeGlobs = parseList(includeGlobs)
+	}
+	if excludeGlobs := os.Getenv("QB_SYNC_EXCLUDE_GLOBS"); excludeGlobs != "" {
+		cfg.Filter.ExcludeGlobs = parseList(excludeGlobs)
+	}
+	if includeRegex := os.Getenv("QB_SYNC_INCLUDE_REGEX"); includeRegex != "" {
+		cfg.Filter.IncludeRegex = includeRegex
+	}
+	if excludeRegex := os.Getenv("QB_SYNC_EXCLUDE_REGEX"); excludeRegex != "" {
+		cfg.Filter.ExcludeRegex = excludeRegex
+	}
+	if minSize := os.Getenv("QB_SYNC_MIN_FILE_SIZE"); minSize != "" {
+		size, err := ParseSize(minSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid QB_SYNC_MIN_FILE_SIZE: %w", err)
+		}
+		cfg.Filter.MinSize = size
+	}
+	if skipSamples := os.Getenv("QB_SYNC_SKIP_SAMPLES"); skipSamples != "" {
+		cfg.Filter.SkipSamples = skipSamples == "true" || skipSamples == "1"
+	}
+	if sampleRatio := os.Getenv("QB_SYNC_SAMPLE_RATIO"); sampleRatio != "" {
+		if ratio, err := strconv.ParseFloat(sampleRatio, 64); err == nil {
+			cfg.Filter.SampleRatio = ratio
+		}
+	}
 
 	// Apply environment variable overrides for PlexConfig
 	if plexURL := os.Getenv("QB_SYNC_PLEX_URL"); plexURL != "" {
@@ -116,6 +373,30 @@ func LoadConfig() (*Config, error) {
 	if plexEnabled := os.Getenv("QB_SYNC_PLEX_ENABLED"); plexEnabled != "" {
 		cfg.Plex.Enabled = plexEnabled == "true" || plexEnabled == "1"
 	}
+	if plexPathMappings := os.Getenv("QB_SYNC_PLEX_PATH_MAPPINGS"); plexPathMappings != "" {
+		rules, err := pathmap.Parse(plexPathMappings)
+		if err != nil {
+			return nil, fmt.Errorf("invalid QB_SYNC_PLEX_PATH_MAPPINGS: %w", err)
+		}
+		cfg.Plex.PathMappings = rules
+	}
+
+	// Apply environment variable overrides for RcloneConfig
+	if rcloneURL := os.Getenv("QB_SYNC_RCLONE_URL"); rcloneURL != "" {
+		cfg.Rclone.URL = rcloneURL
+	}
+	if rcloneUsername := os.Getenv("QB_SYNC_RCLONE_USERNAME"); rcloneUsername != "" {
+		cfg.Rclone.Username = rcloneUsername
+	}
+	if rclonePassword := os.Getenv("QB_SYNC_RCLONE_PASSWORD"); rclonePassword != "" {
+		cfg.Rclone.Password = rclonePassword
+	}
+	if rcloneMountPath := os.Getenv("QB_SYNC_RCLONE_MOUNT_PATH"); rcloneMountPath != "" {
+		cfg.Rclone.MountPath = rcloneMountPath
+	}
+	if rcloneEnabled := os.Getenv("QB_SYNC_RCLONE_ENABLED"); rcloneEnabled != "" {
+		cfg.Rclone.Enabled = rcloneEnabled == "true" || rcloneEnabled == "1"
+	}
 
 	// Apply environment variable overrides for TelegramConfig
 	if telegramToken := os.Getenv("QB_SYNC_TELEGRAM_TOKEN"); telegramToken != "" {
@@ -134,6 +415,14 @@ func LoadConfig() (*Config, error) {
 		cfg.Telegram.Enabled = telegramEnabled == "true" || telegramEnabled == "1"
 	}
 
+	// Apply environment variable overrides for HTTPConfig
+	if httpListen := os.Getenv("QB_SYNC_HTTP_LISTEN"); httpListen != "" {
+		cfg.HTTP.Listen = httpListen
+	}
+	if httpToken := os.Getenv("QB_SYNC_HTTP_TOKEN"); httpToken != "" {
+		cfg.HTTP.Token = httpToken
+	}
+
 
 	// Set defaults (only for non-required fields)
 	if cfg.Monitor.PollInterval == 0 {
@@ -148,7 +437,50 @@ func LoadConfig() (*Config, error) {
 	if cfg.Monitor.LogLevel == "" {
 		cfg.Monitor.LogLevel = "info"
 	}
+	if cfg.Monitor.ConflictPolicy == "" {
+		cfg.Monitor.ConflictPolicy = "fail"
+	}
+	if cfg.Monitor.WatchDebounce == 0 {
+		cfg.Monitor.WatchDebounce = 10 * time.Second
+	}
+	if cfg.Monitor.WatchPollInterval == 0 {
+		cfg.Monitor.WatchPollInterval = 5 * time.Minute
+	}
+	if cfg.Monitor.QualityProfile.Resolutions == nil {
+		cfg.Monitor.QualityProfile.Resolutions = release.DefaultQualityProfile.Resolutions
+	}
+	if cfg.Monitor.QualityProfile.Sources == nil {
+		cfg.Monitor.QualityProfile.Sources = release.DefaultQualityProfile.Sources
+	}
+	if cfg.Monitor.RecycleDir == "" && cfg.Monitor.DestPath != "" {
+		cfg.Monitor.RecycleDir = filepath.Join(cfg.Monitor.DestPath, ".qb-sync-recycle")
+	}
+	if cfg.Monitor.StateDir == "" && cfg.Monitor.DestPath != "" {
+		cfg.Monitor.StateDir = filepath.Join(cfg.Monitor.DestPath, ".qb-sync-state")
+	}
+	if cfg.Monitor.BrokenAction == "" {
+		cfg.Monitor.BrokenAction = "none"
+	}
+	if cfg.Monitor.BrokenGracePeriod == 0 {
+		cfg.Monitor.BrokenGracePeriod = 15 * time.Minute
+	}
+	if cfg.Monitor.BrokenMaxAttempts == 0 {
+		cfg.Monitor.BrokenMaxAttempts = 3
+	}
+	if cfg.Monitor.QuarantineTag == "" {
+		cfg.Monitor.QuarantineTag = "qb-sync-quarantine"
+	}
+	if cfg.Monitor.ReadyTimeout == 0 {
+		cfg.Monitor.ReadyTimeout = time.Minute
+	}
+	if cfg.Monitor.ReadyInterval == 0 {
+		cfg.Monitor.ReadyInterval = 5 * time.Second
+	}
 	
+	if cfg.Filter.SampleRatio == 0 {
+		cfg.Filter.SampleRatio = 0.3
+	}
+
 	// Set optional QB defaults
 	if cfg.QB.Username == "" {
 		cfg.QB.Username = cfg.Monitor.Category
@@ -162,6 +494,11 @@ func LoadConfig() (*Config, error) {
 		cfg.Plex.URL = "http://localhost:32400"
 	}
 
+	// Set optional rclone defaults
+	if cfg.Rclone.URL == "" {
+		cfg.Rclone.URL = "http://localhost:5572"
+	}
+
 	// Validate configuration
 	if err := validateConfig(&cfg); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
@@ -200,6 +537,110 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("monitor.cross_device_fallback must be 'copy' or 'error'")
 	}
 	
+	// Validate conflict policy
+	switch cfg.Monitor.ConflictPolicy {
+	case "skip", "overwrite", "keep-both", "replace-if-larger", "replace-if-better", "fail":
+	default:
+		return fmt.Errorf("monitor.conflict_policy must be 'skip', 'overwrite', 'keep-both', 'replace-if-larger', 'replace-if-better' or 'fail'")
+	}
+
+	// Validate recycle bin
+	if cfg.Monitor.RecycleRetention < 0 {
+		return fmt.Errorf("monitor.recycle_retention must not be negative")
+	}
+
+	// Validate filesystem watcher
+	if cfg.Monitor.WatchDebounce <= 0 || cfg.Monitor.WatchPollInterval <= 0 {
+		return fmt.Errorf("monitor.watch_debounce and monitor.watch_poll_interval must be positive")
+	}
+
+	// Validate plan format
+	switch cfg.Monitor.PlanFormat {
+	case "", "json", "yaml", "yml":
+	default:
+		return fmt.Errorf("monitor.plan_format must be 'json' or 'yaml'")
+	}
+
+	// Validate ownership and permissions
+	if err := validatePermissions(&cfg.Monitor); err != nil {
+		return err
+	}
+
+	// Validate orphan detection
+	if cfg.Monitor.OrphanInterval < 0 {
+		return fmt.Errorf("monitor.orphan_interval must not be negative")
+	}
+	// A link count of 1 only means "source gone" if every imported file is a hardlink that keeps its source
+	if cfg.Monitor.OrphanHardlinks && (cfg.Monitor.Operation != "hardlink" || cfg.Monitor.CrossDeviceFallback != "error" ||
+		(cfg.Monitor.DeleteTorrent && cfg.Monitor.DeleteFiles) || cfg.Monitor.ExtractArchives) {
+		return fmt.Errorf("monitor.orphan_hardlinks requires operation 'hardlink' and cross_device_fallback 'error' without delete_files and extract_archives")
+	}
+
+	// Validate broken torrent handling
+	if cfg.Monitor.BrokenAction != "none" && cfg.Monitor.BrokenAction != "recheck" && cfg.Monitor.BrokenAction != "reannounce" {
+		return fmt.Errorf("monitor.broken_action must be 'none', 'recheck' or 'reannounce'")
+	}
+	if cfg.Monitor.BrokenGracePeriod <= 0 {
+		return fmt.Errorf("monitor.broken_grace_period must be positive")
+	}
+	if cfg.Monitor.BrokenMaxAttempts < 1 {
+		return fmt.Errorf("monitor.broken_max_attempts must be at least 1")
+	}
+	if strings.Contains(cfg.Monitor.QuarantineTag, ",") {
+		return fmt.Errorf("monitor.quarantine_tag must not contain commas")
+	}
+
+	// Validate readiness check
+	if cfg.Monitor.ReadyTimeout < 0 {
+		return fmt.Errorf("monitor.ready_timeout must not be negative")
+	}
+	if cfg.Monitor.ReadyInterval <= 0 {
+		return fmt.Errorf("monitor.ready_interval must be positive")
+	}
+
+	// Validate naming templates
+	if cfg.Monitor.MovieTemplate != "" {
+		if _, err := release.ParseTemplate(cfg.Monitor.MovieTemplate); err != nil {
+			return fmt.Errorf("monitor.movie_template is invalid: %w", err)
+		}
+	}
+	if cfg.Monitor.EpisodeTemplate != "" {
+		if _, err := release.ParseTemplate(cfg.Monitor.EpisodeTemplate); err != nil {
+			return fmt.Errorf("monitor.episode_template is invalid: %w", err)
+		}
+	}
+
+	// Validate file filters
+	if cfg.Filter.IncludeRegex != "" {
+		if _, err := regexp.Compile(cfg.Filter.IncludeRegex); err != nil {
+			return fmt.Errorf("filter.include_regex is invalid: %w", err)
+		}
+	}
+	if cfg.Filter.ExcludeRegex != "" {
+		if _, err := regexp.Compile(cfg.Filter.ExcludeRegex); err != nil {
+			return fmt.Errorf("filter.exclude_regex is invalid: %w", err)
+		}
+	}
+	for _, pattern := range append(append([]string{}, cfg.Filter.IncludeGlobs...), cfg.Filter.ExcludeGlobs...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("filter glob %q is invalid: %w", pattern, err)
+		}
+	}
+	if cfg.Filter.SampleRatio <= 0 || cfg.Filter.SampleRatio >= 1 {
+		return fmt.Errorf("filter.sample_ratio must be between 0 and 1")
+	}
+
+	// Validate that mapped source roots exist locally
+	for _, rule := range cfg.Monitor.PathMappings {
+		info, err := os.Stat(rule.To)
+		if err != nil {
+			return fmt.Errorf("monitor.path_mappings: local root %s for %s is not accessible: %w", rule.To, rule.From, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("monitor.path_mappings: local root %s for %s is not a directory", rule.To, rule.From)
+		}
+	}
+
 	// Validate log level
 	validLogLevels := map[string]bool{
 		"debug": true,
@@ -221,6 +662,16 @@ func validateConfig(cfg *Config) error {
 		}
 	}
 
+	// Validate rclone configuration if enabled
+	if cfg.Rclone.Enabled {
+		if cfg.Rclone.URL == "" {
+			return fmt.Errorf("rclone.url is required when rclone.enabled is true (set via QB_SYNC_RCLONE_URL environment variable)")
+		}
+		if cfg.Rclone.MountPath == "" {
+			return fmt.Errorf("rclone.mount_path is required when rclone.enabled is true (set via QB_SYNC_RCLONE_MOUNT_PATH environment variable)")
+		}
+	}
+
 	// Validate Telegram configuration if enabled
 	if cfg.Telegram.Enabled {
 		if cfg.Telegram.Token == "" {
@@ -231,5 +682,108 @@ func validateConfig(cfg *Config) error {
 		}
 	}
 
+	// The HTTP server triggers imports, so it never runs without authentication
+	if cfg.HTTP.Listen != "" && cfg.HTTP.Token == "" {
+		return fmt.Errorf("http.token is required when http.listen is set (set via QB_SYNC_HTTP_TOKEN environment variable)")
+	}
+
 	return nil
-}
\ No newline at end of file
+}
+
+// validatePermissions checks the ownership and mode settings against what the process may do
+func validatePermissions(cfg *MonitorConfig) error {
+	if cfg.FileMode&^0o7777 != 0 {
+		return fmt.Errorf("monitor.file_mode %o is not a valid permission mode", cfg.FileMode)
+	}
+	if cfg.DirMode&^0o7777 != 0 {
+		return fmt.Errorf("monitor.dir_mode %o is not a valid permission mode", cfg.DirMode)
+	}
+	if cfg.DirMode != 0 && cfg.DirMode&0o700 != 0o700 {
+		return fmt.Errorf("monitor.dir_mode %o must allow the owner to read, write and enter directories", cfg.DirMode)
+	}
+	if cfg.Umask > 0o777 {
+		return fmt.Errorf("monitor.umask %o is not a valid umask", cfg.Umask)
+	}
+	if cfg.Umask != -1 && runtime.GOOS == "windows" {
+		return fmt.Errorf("monitor.umask is not supported on %s", runtime.GOOS)
+	}
+	if cfg.UID < -1 || cfg.GID < -1 {
+		return fmt.Errorf("monitor.uid and monitor.gid must be -1 or a valid ID")
+	}
+	if cfg.UID == -1 && cfg.GID == -1 {
+		return nil
+	}
+
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("monitor.uid and monitor.gid are not supported on %s", runtime.GOOS)
+	}
+	euid := os.Geteuid()
+	if euid == 0 {
+		return nil
+	}
+	// Without root, files can only be given to ourselves and to groups we belong to
+	if cfg.UID != -1 && cfg.UID != euid {
+		return fmt.Errorf("monitor.uid %d requires running as root (running as uid %d)", cfg.UID, euid)
+	}
+	if cfg.GID != -1 && cfg.GID != os.Getegid() {
+		groups, err := os.Getgroups()
+		if err != nil {
+			return fmt.Errorf("failed to check group membership: %w", err)
+		}
+		for _, group := range groups {
+			if group == cfg.GID {
+				return nil
+			}
+		}
+		return fmt.Errorf("monitor.gid %d requires running as root or as a member of that group", cfg.GID)
+	}
+	return nil
+}
+
+// parseList splits a comma separated list, dropping empty entries
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseExtensions parses a comma separated extension list into lower-case ".ext" form
+func parseExtensions(value string) []string {
+	var extensions []string
+	for _, ext := range parseList(value) {
+		extensions = append(extensions, "."+strings.ToLower(strings.TrimPrefix(ext, ".")))
+	}
+	return extensions
+}
+
+// ParseSize parses a byte size such as "500", "50MB" or "1.5GiB" (binary units)
+func ParseSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	units := []struct {
+		suffix string
+		factor float64
+	}{
+		{"TIB", 1 << 40}, {"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+	}
+
+	factor := 1.0
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			factor = unit.factor
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return int64(number * factor), nil
+}
@@ -0,0 +1,283 @@
+package files
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nwaples/rardecode"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/perms"
+	"qb-sync/internal/qbit"
+)
+
+// Archive kinds
+const (
+	ArchiveRar = "rar"
+	ArchiveZip = "zip"
+)
+
+var (
+	// partVolumePattern matches new style RAR volumes: name.part01.rar
+	partVolumePattern = regexp.MustCompile(`(?i)^(.*)\.part(\d+)\.rar$`)
+	// oldVolumePattern matches old style RAR continuation volumes: name.r00, name.s00
+	oldVolumePattern = regexp.MustCompile(`(?i)^(.*)\.[r-z]\d{2}$`)
+)
+
+// ArchiveSet groups all volumes of one archive within a torrent
+type ArchiveSet struct {
+	Kind    string
+	First   ResolvedFile   // Volume extraction starts from
+	Volumes []ResolvedFile // All volumes, including the first
+}
+
+// Size returns the total size of all volumes
+func (a *ArchiveSet) Size() int64 {
+	var size int64
+	for _, volume := range a.Volumes {
+		size += volume.Size
+	}
+	return size
+}
+
+// DetectArchives groups archive volumes in a torrent's file list.
+// It returns the archive sets and the files that are not part of any set.
+func DetectArchives(torrentFiles []ResolvedFile) ([]*ArchiveSet, []ResolvedFile) {
+	sets := make(map[string]*ArchiveSet)
+	var order []string
+	partNumbers := make(map[string]int)
+
+	addVolume := func(key, kind string, file ResolvedFile, first bool) {
+		set, ok := sets[key]
+		if !ok {
+			set = &ArchiveSet{Kind: kind}
+			sets[key] = set
+			order = append(order, key)
+		}
+		set.Volumes = append(set.Volumes, file)
+		if first {
+			set.First = file
+		}
+	}
+
+	// First pass: find the sets by their first volume
+	for _, file := range torrentFiles {
+		lower := strings.ToLower(file.RelPath)
+		switch {
+		case partVolumePattern.MatchString(file.RelPath):
+			match := partVolumePattern.FindStringSubmatch(file.RelPath)
+			key := strings.ToLower(match[1])
+			number, _ := strconv.Atoi(match[2])
+			current, seen := partNumbers[key]
+			addVolume(key, ArchiveRar, file, !seen || number < current)
+			if !seen || number < current {
+				partNumbers[key] = number
+			}
+		case strings.HasSuffix(lower, ".rar"):
+			addVolume(strings.TrimSuffix(lower, ".rar"), ArchiveRar, file, true)
+		case strings.HasSuffix(lower, ".zip"):
+			addVolume(lower, ArchiveZip, file, true)
+		}
+	}
+
+	// Second pass: attach old style continuation volumes and collect the rest
+	var remaining []ResolvedFile
+	for _, file := range torrentFiles {
+		lower := strings.ToLower(file.RelPath)
+		if match := oldVolumePattern.FindStringSubmatch(lower); match != nil {
+			if set, ok := sets[match[1]]; ok && set.Kind == ArchiveRar {
+				set.Volumes = append(set.Volumes, file)
+				continue
+			}
+		}
+		if partVolumePattern.MatchString(file.RelPath) || strings.HasSuffix(lower, ".rar") || strings.HasSuffix(lower, ".zip") {
+			continue
+		}
+		remaining = append(remaining, file)
+	}
+
+	var result []*ArchiveSet
+	for _, key := range order {
+		set := sets[key]
+		// Keep the first volume first, followed by the others in name order
+		sort.Slice(set.Volumes, func(i, j int) bool {
+			if (set.Volumes[i].RelPath == set.First.RelPath) != (set.Volumes[j].RelPath == set.First.RelPath) {
+				return set.Volumes[i].RelPath == set.First.RelPath
+			}
+			return set.Volumes[i].RelPath < set.Volumes[j].RelPath
+		})
+		result = append(result, set)
+	}
+	return result, remaining
+}
+
+// ExtractArchive extracts an archive set into the destination instead of linking its volumes.
+// Every extracted file is verified against the size recorded in the archive.
+func ExtractArchive(cfg *config.MonitorConfig, torrent *qbit.Torrent, set *ArchiveSet) ([]*FileOperation, error) {
+	switch set.Kind {
+	case ArchiveRar:
+		return extractRar(cfg, torrent, set)
+	case ArchiveZip:
+		return extractZip(cfg, torrent, set)
+	default:
+		return nil, fmt.Errorf("unsupported archive kind: %s", set.Kind)
+	}
+}
+
+// extractRar extracts a (multi-volume) RAR archive
+func extractRar(cfg *config.MonitorConfig, torrent *qbit.Torrent, set *ArchiveSet) ([]*FileOperation, error) {
+	reader, err := rardecode.OpenReader(set.First.Source, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open RAR archive: %w", err)
+	}
+	defer reader.Close()
+
+	var ops []*FileOperation
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ops, fmt.Errorf("failed to read RAR archive: %w", err)
+		}
+		if header.IsDir {
+			continue
+		}
+
+		size := header.UnPackedSize
+		if header.UnKnownSize {
+			size = -1
+		}
+		op, err := extractEntry(cfg, torrent, set, header.Name, size, header.ModificationTime, reader)
+		ops = append(ops, op)
+		if err != nil {
+			return ops, err
+		}
+	}
+	return ops, nil
+}
+
+// extractZip extracts a ZIP archive
+func extractZip(cfg *config.MonitorConfig, torrent *qbit.Torrent, set *ArchiveSet) ([]*FileOperation, error) {
+	reader, err := zip.OpenReader(set.First.Source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ZIP archive: %w", err)
+	}
+	defer reader.Close()
+
+	var ops []*FileOperation
+	for _, entry := range reader.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+
+		rc, err := entry.Open()
+		if err != nil {
+			return ops, fmt.Errorf("failed to open ZIP entry %s: %w", entry.Name, err)
+		}
+		op, err := extractEntry(cfg, torrent, set, entry.Name, int64(entry.UncompressedSize64), entry.Modified, rc)
+		rc.Close()
+		ops = append(ops, op)
+		if err != nil {
+			return ops, err
+		}
+	}
+	return ops, nil
+}
+
+// extractEntry writes a single archive entry to its destination.
+// A negative expected size skips size verification.
+func extractEntry(cfg *config.MonitorConfig, torrent *qbit.Torrent, set *ArchiveSet, name string, expectedSize int64, modTime time.Time, r io.Reader) (*FileOperation, error) {
+	op := &FileOperation{
+		Source: set.First.Source,
+		Entry:  name,
+		Size:   expectedSize,
+		Method: MethodExtract,
+	}
+
+	// Reject entries that would escape the destination
+	entryPath := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(entryPath) || entryPath == ".." || strings.HasPrefix(entryPath, ".."+string(filepath.Separator)) {
+		op.Error = fmt.Errorf("refusing to extract unsafe archive entry: %s", name)
+		return op, op.Error
+	}
+
+	// Extracted files are placed like a torrent file next to the archive
+	entryFile := &ResolvedFile{
+		TorrentFile: qbit.TorrentFile{Name: filepath.Join(filepath.Dir(filepath.FromSlash(set.First.Name)), entryPath), Size: expectedSize},
+		RelPath:     filepath.Join(filepath.Dir(set.First.RelPath), entryPath),
+	}
+	destPath, err := BuildDestPath(cfg, torrent, entryFile)
+	if err != nil {
+		op.Error = fmt.Errorf("failed to build destination path: %w", err)
+		return op, op.Error
+	}
+	conflict, err := resolveConflict(cfg, destPath, expectedSize, MediaInfo(torrent, entryFile))
+	if err != nil {
+		op.Error = err
+		return op, op.Error
+	}
+	op.Destination = conflict.Destination
+	op.Decision = conflict.Decision
+	if !conflict.Write {
+		op.Success = true
+		return op, nil
+	}
+
+	if err := perms.MkdirAll(cfg, filepath.Dir(op.Destination)); err != nil {
+		op.Error = fmt.Errorf("failed to create destination directory: %w", err)
+		return op, op.Error
+	}
+
+	// Write to a temporary file so a failed extraction never looks complete
+	var written int64
+	err = writeReplacing(cfg, op.Destination, "replaced at destination", func(path string) error {
+		var err error
+		written, err = writeFile(path, r)
+		if err == nil && expectedSize >= 0 && written != expectedSize {
+			err = fmt.Errorf("size mismatch: expected %d, got %d", expectedSize, written)
+		}
+		if err == nil && !modTime.IsZero() {
+			err = os.Chtimes(path, time.Now(), modTime)
+		}
+		if err == nil {
+			err = perms.ApplyFile(cfg, path)
+		}
+		return err
+	})
+	if err != nil {
+		op.Error = fmt.Errorf("failed to extract %s: %w", name, err)
+		return op, op.Error
+	}
+
+	op.Size = written
+	op.Success = true
+	return op, nil
+}
+
+// writeFile writes the reader's content to a new file and syncs it
+func writeFile(path string, r io.Reader) (int64, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create destination file: %w", err)
+	}
+	defer file.Close()
+
+	written, err := io.Copy(file, r)
+	if err != nil {
+		return written, fmt.Errorf("failed to write file content: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return written, fmt.Errorf("failed to sync destination file: %w", err)
+	}
+	return written, nil
+}
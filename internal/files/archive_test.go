@@ -0,0 +1,158 @@
+package files
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/qbit"
+)
+
+func TestDetectArchives(t *testing.T) {
+	type set struct {
+		kind    string
+		first   string
+		volumes []string
+	}
+
+	tests := []struct {
+		name      string
+		files     []string
+		want      []set
+		remaining []string
+	}{
+		{
+			name:  "new style volumes",
+			files: []string{"Movie.part02.rar", "Movie.part01.rar", "Movie.part03.rar", "Movie.nfo"},
+			want: []set{
+				{ArchiveRar, "Movie.part01.rar", []string{"Movie.part01.rar", "Movie.part02.rar", "Movie.part03.rar"}},
+			},
+			remaining: []string{"Movie.nfo"},
+		},
+		{
+			name:  "old style volumes",
+			files: []string{"movie.r01", "movie.rar", "movie.r00", "movie.s00", "Sample/movie-sample.mkv"},
+			want: []set{
+				{ArchiveRar, "movie.rar", []string{"movie.rar", "movie.r00", "movie.r01", "movie.s00"}},
+			},
+			remaining: []string{"Sample/movie-sample.mkv"},
+		},
+		{
+			name:  "extensions in any case",
+			files: []string{"Movie.R00", "Movie.RAR"},
+			want: []set{
+				{ArchiveRar, "Movie.RAR", []string{"Movie.RAR", "Movie.R00"}},
+			},
+		},
+		{
+			name:  "single zip",
+			files: []string{"Subs.zip", "Movie.mkv"},
+			want: []set{
+				{ArchiveZip, "Subs.zip", []string{"Subs.zip"}},
+			},
+			remaining: []string{"Movie.mkv"},
+		},
+		{
+			name:  "sets per folder",
+			files: []string{"CD1/movie.rar", "CD1/movie.r00", "CD2/movie.rar", "CD2/movie.r00"},
+			want: []set{
+				{ArchiveRar, "CD1/movie.rar", []string{"CD1/movie.rar", "CD1/movie.r00"}},
+				{ArchiveRar, "CD2/movie.rar", []string{"CD2/movie.rar", "CD2/movie.r00"}},
+			},
+		},
+		{
+			name:      "continuation volumes without a first volume",
+			files:     []string{"movie.r00", "movie.r01"},
+			remaining: []string{"movie.r00", "movie.r01"},
+		},
+		{
+			name:      "no archives",
+			files:     []string{"Movie.mkv", "Movie.srt"},
+			remaining: []string{"Movie.mkv", "Movie.srt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var torrentFiles []ResolvedFile
+			for _, name := range tt.files {
+				torrentFiles = append(torrentFiles, ResolvedFile{TorrentFile: qbit.TorrentFile{Name: name}, RelPath: name})
+			}
+
+			sets, remaining := DetectArchives(torrentFiles)
+			var got []set
+			for _, archive := range sets {
+				found := set{kind: archive.Kind, first: archive.First.RelPath}
+				for _, volume := range archive.Volumes {
+					found.volumes = append(found.volumes, volume.RelPath)
+				}
+				got = append(got, found)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sets = %v, want %v", got, tt.want)
+			}
+
+			var rest []string
+			for _, file := range remaining {
+				rest = append(rest, file.RelPath)
+			}
+			if !reflect.DeepEqual(rest, tt.remaining) {
+				t.Errorf("remaining = %v, want %v", rest, tt.remaining)
+			}
+		})
+	}
+}
+
+func TestExtractZipRejectsUnsafeEntries(t *testing.T) {
+	tests := []string{"../evil.txt", "Subs/../../evil.txt", "/evil.txt"}
+
+	for _, entry := range tests {
+		t.Run(entry, func(t *testing.T) {
+			root := t.TempDir()
+			download := filepath.Join(root, "dl")
+			dest := filepath.Join(root, "dest", "inner")
+			if err := os.MkdirAll(download, 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			archive := filepath.Join(download, "Subs.zip")
+			out, err := os.Create(archive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writer := zip.NewWriter(out)
+			w, err := writer.Create(entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte("evil"))
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+			out.Close()
+			info, err := os.Stat(archive)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := &config.MonitorConfig{DestPath: dest, ConflictPolicy: ConflictFail, UID: -1, GID: -1, Umask: -1}
+			torrent := &qbit.Torrent{Name: "Subs.zip", SavePath: download, ContentPath: archive}
+			sets, _ := DetectArchives(ResolveFiles(cfg, torrent, []qbit.TorrentFile{{Name: "Subs.zip", Size: info.Size()}}))
+			if len(sets) != 1 {
+				t.Fatalf("got %d archive sets, want 1", len(sets))
+			}
+
+			if _, err := ExtractArchive(cfg, torrent, sets[0]); err == nil {
+				t.Fatalf("extracting %q succeeded, want an error", entry)
+			}
+			for _, path := range []string{filepath.Join(root, "evil.txt"), filepath.Join(root, "dest", "evil.txt"), "/evil.txt"} {
+				if _, err := os.Lstat(path); err == nil {
+					t.Errorf("%s was written outside the destination", path)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package files
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/qbit"
+	"qb-sync/internal/release"
+)
+
+// subtitleExtensions lists extensions treated as subtitles
+var subtitleExtensions = map[string]bool{
+	".srt": true, ".ass": true, ".ssa": true, ".sub": true, ".idx": true,
+}
+
+// languageCodes maps language names and ISO 639 codes found in subtitle names to ISO 639-1
+var languageCodes = map[string]string{
+	"en": "en", "eng": "en", "english": "en",
+	"de": "de", "ger": "de", "deu": "de", "german": "de", "deutsch": "de",
+	"fr": "fr", "fre": "fr", "fra": "fr", "french": "fr", "francais": "fr",
+	"es": "es", "spa": "es", "spanish": "es", "espanol": "es", "castellano": "es",
+	"it": "it", "ita": "it", "italian": "it", "italiano": "it",
+	"nl": "nl", "dut": "nl", "nld": "nl", "dutch": "nl",
+	"pt": "pt", "por": "pt", "portuguese": "pt", "brazilian": "pt",
+	"ru": "ru", "rus": "ru", "russian": "ru",
+	"pl": "pl", "pol": "pl", "polish": "pl",
+	"sv": "sv", "swe": "sv", "swedish": "sv",
+	"da": "da", "dan": "da", "danish": "da",
+	"no": "no", "nor": "no", "norwegian": "no",
+	"fi": "fi", "fin": "fi", "finnish": "fi",
+	"cs": "cs", "cze": "cs", "ces": "cs", "czech": "cs",
+	"hu": "hu", "hun": "hu", "hungarian": "hu",
+	"tr": "tr", "tur": "tr", "turkish": "tr",
+	"el": "el", "gre": "el", "ell": "el", "greek": "el",
+	"ar": "ar", "ara": "ar", "arabic": "ar",
+	"he": "he", "heb": "he", "hebrew": "he",
+	"ja": "ja", "jpn": "ja", "japanese": "ja",
+	"ko": "ko", "kor": "ko", "korean": "ko",
+	"zh": "zh", "chi": "zh", "zho": "zh", "chinese": "zh",
+}
+
+// subtitleFlags are name tokens describing a subtitle rather than its language
+var subtitleFlags = map[string]string{
+	"forced": "forced",
+	"sdh":    "sdh",
+	"hi":     "sdh",
+	"cc":     "sdh",
+}
+
+// extrasFolders maps folder names used by releases to Plex's extras folders
+var extrasFolders = map[string]string{
+	"featurette":        "Featurettes",
+	"featurettes":       "Featurettes",
+	"behind the scenes": "Behind The Scenes",
+	"behindthescenes":   "Behind The Scenes",
+	"deleted scenes":    "Deleted Scenes",
+	"deleted":           "Deleted Scenes",
+	"interview":         "Interviews",
+	"interviews":        "Interviews",
+	"scenes":            "Scenes",
+	"shorts":            "Shorts",
+	"trailer":           "Trailers",
+	"trailers":          "Trailers",
+	"extras":            "Other",
+	"bonus":             "Other",
+	"other":             "Other",
+}
+
+var (
+	tokenPattern        = regexp.MustCompile(`[a-z0-9]+`)
+	extrasSuffixPattern = regexp.MustCompile(`(?i)-(trailer|featurette|behindthescenes|deleted|interview|scene|short|other)$`)
+)
+
+// IsSubtitle reports whether a file name has a subtitle extension
+func IsSubtitle(name string) bool {
+	return subtitleExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// mainVideo is a planned video that subtitles and extras are placed next to
+type mainVideo struct {
+	op   *PlannedOp
+	stem string // original file name without extension, lower case
+	info release.Info
+}
+
+// placeCompanions moves planned subtitles next to their main video using Plex's
+// Name.lang.forced.ext convention and moves extras into Plex's extras folders
+func placeCompanions(cfg *config.MonitorConfig, torrent *qbit.Torrent, plan []PlannedOp) {
+	var mains []mainVideo
+	for i := range plan {
+		op := &plan[i]
+		if op.File == nil || !IsVideo(op.File.Name) || isExtra(op.File.RelPath) {
+			continue
+		}
+		mains = append(mains, mainVideo{
+			op:   op,
+			stem: strings.ToLower(stem(op.File.RelPath)),
+			info: MediaInfo(torrent, op.File),
+		})
+	}
+	if len(mains) == 0 {
+		return
+	}
+
+	used := make(map[string]bool)
+	for i := range plan {
+		used[plan[i].Destination] = true
+	}
+
+	for i := range plan {
+		op := &plan[i]
+		if op.File == nil {
+			continue
+		}
+
+		switch {
+		case cfg.RenameSubtitles && IsSubtitle(op.File.Name):
+			main := matchMainVideo(torrent, op.File, mains)
+			if main == nil {
+				continue
+			}
+			delete(used, op.Destination)
+			dest := subtitleDestination(main.op.Destination, op.File.RelPath, used)
+			used[dest] = true
+			op.Destination = dest
+
+		case cfg.PlaceExtras && IsVideo(op.File.Name):
+			folder := extrasFolder(op.File.RelPath)
+			if folder == "" {
+				continue
+			}
+			main := matchMainVideo(torrent, op.File, mains)
+			if main == nil {
+				main = &mains[0]
+			}
+			dest := filepath.Join(filepath.Dir(main.op.Destination), folder, filepath.Base(op.File.RelPath))
+			delete(used, op.Destination)
+			used[dest] = true
+			op.Destination = dest
+		}
+	}
+}
+
+// matchMainVideo finds the main video a subtitle or extra belongs to
+func matchMainVideo(torrent *qbit.Torrent, file *ResolvedFile, mains []mainVideo) *mainVideo {
+	if len(mains) == 1 {
+		return &mains[0]
+	}
+
+	// Subtitles named after their video, e.g. Movie.2020.1080p.en.srt
+	name := strings.ToLower(stem(file.RelPath))
+	for i := range mains {
+		if strings.HasPrefix(name, mains[i].stem) {
+			return &mains[i]
+		}
+	}
+
+	// Episode subtitles, possibly in per-episode folders such as Subs/Show.S01E02/2_English.srt
+	for _, candidate := range []string{filepath.Base(file.RelPath), filepath.Base(filepath.Dir(file.RelPath))} {
+		info := release.Parse(candidate)
+		if !info.IsEpisode || info.Episode == 0 {
+			continue
+		}
+		for i := range mains {
+			if mains[i].info.Episode == info.Episode && (info.Season == 0 || mains[i].info.Season == info.Season) {
+				return &mains[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// subtitleDestination builds Name.lang.flags.ext next to the main video's destination
+func subtitleDestination(videoDest, subtitlePath string, used map[string]bool) string {
+	lang, flags := subtitleLanguage(subtitlePath)
+
+	parts := []string{strings.TrimSuffix(videoDest, filepath.Ext(videoDest))}
+	if lang != "" {
+		parts = append(parts, lang)
+	}
+	parts = append(parts, flags...)
+	base := strings.Join(parts, ".")
+	ext := strings.ToLower(filepath.Ext(subtitlePath))
+
+	// .sub/.idx pairs share the same name; other duplicates get a counter
+	dest := base + ext
+	for n := 2; used[dest]; n++ {
+		dest = fmt.Sprintf("%s.%d%s", base, n, ext)
+	}
+	return dest
+}
+
+// subtitleLanguage infers the language code and flags (forced, sdh) from a subtitle name
+func subtitleLanguage(subtitlePath string) (string, []string) {
+	tokens := tokenPattern.FindAllString(strings.ToLower(stem(subtitlePath)), -1)
+
+	var lang string
+	var flags []string
+	seenFlags := make(map[string]bool)
+	checked := 0
+	for i := len(tokens) - 1; i >= 0 && checked < 3; i-- {
+		token := tokens[i]
+		if flag, ok := subtitleFlags[token]; ok {
+			if !seenFlags[flag] {
+				seenFlags[flag] = true
+				flags = append([]string{flag}, flags...)
+			}
+			continue
+		}
+		checked++
+		if lang != "" {
+			continue
+		}
+		// Two-letter codes are only trusted as the last token, they are common words otherwise
+		if code, ok := languageCodes[token]; ok && (len(token) > 2 || checked == 1) {
+			lang = code
+		}
+	}
+	return lang, flags
+}
+
+// isExtra reports whether a video is an extra rather than main media
+func isExtra(relPath string) bool {
+	return extrasFolder(relPath) != "" || extrasSuffixPattern.MatchString(stem(relPath))
+}
+
+// extrasFolder returns the Plex extras folder for a file, based on its parent folders
+func extrasFolder(relPath string) string {
+	dir := filepath.Dir(relPath)
+	for dir != "." && dir != string(filepath.Separator) {
+		name := strings.ToLower(strings.NewReplacer(".", " ", "_", " ", "-", " ").Replace(filepath.Base(dir)))
+		if folder, ok := extrasFolders[name]; ok {
+			return folder
+		}
+		dir = filepath.Dir(dir)
+	}
+	return ""
+}
+
+// stem returns the base name of a path without its extension
+func stem(path string) string {
+	base := filepath.Base(path)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
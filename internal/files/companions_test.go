@@ -0,0 +1,124 @@
+package files
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/qbit"
+)
+
+func TestSubtitleLanguage(t *testing.T) {
+	tests := []struct {
+		path  string
+		lang  string
+		flags []string
+	}{
+		{"Movie.2020.en.forced.srt", "en", []string{"forced"}},
+		{"Movie.2020.1080p.BluRay.x264-GRP.eng.srt", "en", nil},
+		{"Movie.2020.de.srt", "de", nil},
+		{"Movie.2020.English.SDH.srt", "en", []string{"sdh"}},
+		{"Movie.2020.eng.cc.forced.srt", "en", []string{"sdh", "forced"}},
+		{"Movie.2020.hi.srt", "", []string{"sdh"}},
+		{"Subs/Show.S01E02/2_English.srt", "en", nil},
+		{"Subs/Show.S01E02.German.forced.srt", "de", []string{"forced"}},
+		{"Subs/3_Spanish.Latin.srt", "es", nil},
+		{"Movie.2020.srt", "", nil},
+		// Two-letter codes are words unless they are the last token
+		{"It.2017.srt", "", nil},
+		{"Movie.It.Is.srt", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			lang, flags := subtitleLanguage(filepath.FromSlash(tt.path))
+			if lang != tt.lang || !reflect.DeepEqual(flags, tt.flags) {
+				t.Errorf("subtitleLanguage() = %q %v, want %q %v", lang, flags, tt.lang, tt.flags)
+			}
+		})
+	}
+}
+
+func TestMatchMainVideo(t *testing.T) {
+	tests := []struct {
+		name    string
+		torrent string
+		videos  []string
+		file    string
+		want    string // main video the file belongs to, "" for none
+	}{
+		{
+			name:    "single video takes everything",
+			torrent: "Movie.2020.1080p.BluRay.x264-GRP",
+			videos:  []string{"Movie.2020.1080p.BluRay.x264-GRP.mkv"},
+			file:    "Subs/2_English.srt",
+			want:    "Movie.2020.1080p.BluRay.x264-GRP.mkv",
+		},
+		{
+			name:    "subtitle named after its episode",
+			torrent: "Show.S01.1080p.WEB-DL-GRP",
+			videos:  []string{"Show.S01E01.1080p.WEB-DL-GRP.mkv", "Show.S01E02.1080p.WEB-DL-GRP.mkv"},
+			file:    "Show.S01E02.1080p.WEB-DL-GRP.en.srt",
+			want:    "Show.S01E02.1080p.WEB-DL-GRP.mkv",
+		},
+		{
+			name:    "per-episode subtitle folders",
+			torrent: "Show.S01.1080p.WEB-DL-GRP",
+			videos:  []string{"Show.S01E01.1080p.WEB-DL-GRP.mkv", "Show.S01E02.1080p.WEB-DL-GRP.mkv"},
+			file:    "Subs/Show.S01E02.1080p.WEB-DL-GRP/2_English.srt",
+			want:    "Show.S01E02.1080p.WEB-DL-GRP.mkv",
+		},
+		{
+			name:    "episode marker in a shorter subtitle name",
+			torrent: "Show.S01.1080p.WEB-DL-GRP",
+			videos:  []string{"Show.S01E01.1080p.WEB-DL-GRP.mkv", "Show.S01E02.1080p.WEB-DL-GRP.mkv"},
+			file:    "Subs/Show.S01E01.German.srt",
+			want:    "Show.S01E01.1080p.WEB-DL-GRP.mkv",
+		},
+		{
+			name:    "episode of another season",
+			torrent: "Show.S01-S02.1080p.WEB-DL-GRP",
+			videos:  []string{"S01/Show.S01E01.mkv", "S02/Show.S02E01.mkv"},
+			file:    "Subs/Show.S02E01/English.srt",
+			want:    "S02/Show.S02E01.mkv",
+		},
+		{
+			name:    "no episode to match",
+			torrent: "Show.S01.1080p.WEB-DL-GRP",
+			videos:  []string{"Show.S01E01.1080p.WEB-DL-GRP.mkv", "Show.S01E02.1080p.WEB-DL-GRP.mkv"},
+			file:    "Subs/2_English.srt",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			torrent := &qbit.Torrent{Name: tt.torrent, SavePath: "/dl", RootPath: "/dl/" + tt.torrent, ContentPath: "/dl/" + tt.torrent}
+			var torrentFiles []qbit.TorrentFile
+			for _, name := range append(append([]string{}, tt.videos...), tt.file) {
+				torrentFiles = append(torrentFiles, qbit.TorrentFile{Name: tt.torrent + "/" + name})
+			}
+			resolved := ResolveFiles(&config.MonitorConfig{}, torrent, torrentFiles)
+
+			var mains []mainVideo
+			for i := range resolved[:len(tt.videos)] {
+				file := &resolved[i]
+				mains = append(mains, mainVideo{
+					op:   &PlannedOp{File: file},
+					stem: strings.ToLower(stem(file.RelPath)),
+					info: MediaInfo(torrent, file),
+				})
+			}
+
+			got := ""
+			if main := matchMainVideo(torrent, &resolved[len(tt.videos)], mains); main != nil {
+				got = filepath.ToSlash(main.op.File.RelPath)
+			}
+			if got != tt.want {
+				t.Errorf("matchMainVideo(%s) = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
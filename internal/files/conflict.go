@@ -0,0 +1,150 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/fsutil"
+	"qb-sync/internal/qbit"
+	"qb-sync/internal/release"
+)
+
+// Conflict policies for destinations that already hold a different file
+const (
+	ConflictSkip            = "skip"
+	ConflictOverwrite       = "overwrite"
+	ConflictKeepBoth        = "keep-both"
+	ConflictReplaceIfLarger = "replace-if-larger"
+	ConflictReplaceIfBetter = "replace-if-better"
+	ConflictFail            = "fail"
+)
+
+// Decisions recorded in FileOperation.Decision
+const (
+	DecisionCreated  = "created"   // destination did not exist
+	DecisionExists   = "exists"    // destination already holds the same file
+	DecisionSkipped  = "skipped"   // a different file exists and was left in place
+	DecisionReplaced = "replaced"  // a different file existed and was replaced
+	DecisionKeptBoth = "kept-both" // a different file exists, the new file got a suffixed name
+	DecisionRecycled = "recycled"  // an older release was moved to the recycle bin
+)
+
+// partialSuffix marks files that are still being written
+const partialSuffix = ".qb-sync-partial"
+
+// conflictResult is the outcome of checking a destination against the conflict policy
+type conflictResult struct {
+	Destination string // where the file should be written, may differ from the planned destination
+	Decision    string
+	Write       bool // whether the file must be written
+	Replace     bool // whether an existing file is replaced
+}
+
+// resolveConflict applies the configured conflict policy to a planned destination.
+// info describes the incoming file and is used by replace-if-better.
+func resolveConflict(cfg *config.MonitorConfig, destPath string, size int64, info release.Info) (conflictResult, error) {
+	existing, err := os.Stat(destPath)
+	if os.IsNotExist(err) {
+		if _, lerr := os.Lstat(destPath); lerr == nil {
+			// A dangling symlink is replaced like any other stale file
+			return conflictResult{Destination: destPath, Decision: DecisionReplaced, Write: true, Replace: true}, nil
+		}
+		return conflictResult{Destination: destPath, Decision: DecisionCreated, Write: true}, nil
+	}
+	if err != nil {
+		return conflictResult{}, fmt.Errorf("failed to stat destination: %w", err)
+	}
+	if existing.IsDir() {
+		return conflictResult{}, fmt.Errorf("destination %s is a directory", destPath)
+	}
+
+	// Same size is treated as the same file (idempotency)
+	if size >= 0 && existing.Size() == size {
+		return conflictResult{Destination: destPath, Decision: DecisionExists}, nil
+	}
+
+	replace := conflictResult{Destination: destPath, Decision: DecisionReplaced, Write: true, Replace: true}
+	skip := conflictResult{Destination: destPath, Decision: DecisionSkipped}
+
+	switch cfg.ConflictPolicy {
+	case ConflictSkip:
+		return skip, nil
+	case ConflictOverwrite:
+		return replace, nil
+	case ConflictKeepBoth:
+		// If an earlier keep-both already stored a file of the same size, reuse its
+		// name so repeated runs do not create more copies
+		alternative, exists, err := fsutil.FreeName(destPath, func(candidate string, info os.FileInfo) bool {
+			return size >= 0 && !info.IsDir() && VerifyFileIntegrity(candidate, size)
+		})
+		if err != nil {
+			return conflictResult{}, err
+		}
+		if exists {
+			return conflictResult{Destination: alternative, Decision: DecisionExists}, nil
+		}
+		return conflictResult{Destination: alternative, Decision: DecisionKeptBoth, Write: true}, nil
+	case ConflictReplaceIfLarger:
+		if size > existing.Size() {
+			return replace, nil
+		}
+		return skip, nil
+	case ConflictReplaceIfBetter:
+		// Fall back to size when the quality of either file is unknown or equal
+		current := release.Parse(filepath.Base(destPath))
+		profile := cfg.QualityProfile
+		if profile.Known(info) && profile.Known(current) {
+			switch profile.Compare(info, current) {
+			case 1:
+				return replace, nil
+			case -1:
+				return skip, nil
+			}
+		}
+		if size > existing.Size() {
+			return replace, nil
+		}
+		return skip, nil
+	default:
+		return conflictResult{}, fmt.Errorf("destination %s already exists with a different size (%d bytes, expected %d)", destPath, existing.Size(), size)
+	}
+}
+
+// PreviewConflict reports where a planned operation would write and how the conflict
+// policy would treat an existing destination, without touching the destination.
+// Extractions are decided per archive entry and return an empty decision.
+func PreviewConflict(cfg *config.MonitorConfig, torrent *qbit.Torrent, op *PlannedOp) (string, string, error) {
+	if op.File == nil {
+		return op.Destination, "", nil
+	}
+	result, err := resolveConflict(cfg, op.Destination, op.File.Size, MediaInfo(torrent, op.File))
+	if err != nil {
+		return op.Destination, "", err
+	}
+	return result.Destination, result.Decision, nil
+}
+
+// writeReplacing runs write into a temporary file next to destPath and moves it
+// into place. An existing file is moved to the recycle bin with the given reason only
+// after writing succeeded, so it stays intact if writing fails.
+func writeReplacing(cfg *config.MonitorConfig, destPath, reason string, write func(path string) error) error {
+	tmpPath := destPath + partialSuffix
+	os.Remove(tmpPath)
+	if err := write(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if _, err := os.Lstat(destPath); err == nil {
+		if _, err := Recycle(cfg, destPath, reason); err != nil {
+			os.Remove(tmpPath)
+			return err
+		}
+	}
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to move file into place: %w", err)
+	}
+	return nil
+}
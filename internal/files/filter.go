@@ -0,0 +1,134 @@
+package files
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"qb-sync/internal/config"
+)
+
+// samplePattern matches "sample" as a word in a file path
+var samplePattern = regexp.MustCompile(`(?i)(^|[^a-z])sample([^a-z]|$)`)
+
+// SkippedFile is a torrent file excluded from import by a filter rule
+type SkippedFile struct {
+	ResolvedFile
+	Reason string
+}
+
+// Filter decides which torrent files are imported
+type Filter struct {
+	config       *config.FilterConfig
+	includeRegex *regexp.Regexp
+	excludeRegex *regexp.Regexp
+}
+
+// NewFilter creates a file filter from configuration
+func NewFilter(cfg *config.FilterConfig) (*Filter, error) {
+	filter := &Filter{config: cfg}
+
+	var err error
+	if cfg.IncludeRegex != "" {
+		if filter.includeRegex, err = regexp.Compile(cfg.IncludeRegex); err != nil {
+			return nil, fmt.Errorf("invalid include regex: %w", err)
+		}
+	}
+	if cfg.ExcludeRegex != "" {
+		if filter.excludeRegex, err = regexp.Compile(cfg.ExcludeRegex); err != nil {
+			return nil, fmt.Errorf("invalid exclude regex: %w", err)
+		}
+	}
+
+	return filter, nil
+}
+
+// Apply splits torrent files into the ones to import and the ones skipped by a rule
+func (f *Filter) Apply(torrentFiles []ResolvedFile) ([]ResolvedFile, []SkippedFile) {
+	// Sample detection is relative to the largest video in the torrent
+	var largestVideo int64
+	for _, file := range torrentFiles {
+		if IsVideo(file.Name) && file.Size > largestVideo {
+			largestVideo = file.Size
+		}
+	}
+
+	var kept []ResolvedFile
+	var skipped []SkippedFile
+	for _, file := range torrentFiles {
+		if reason := f.skipReason(&file, largestVideo); reason != "" {
+			skipped = append(skipped, SkippedFile{ResolvedFile: file, Reason: reason})
+			continue
+		}
+		kept = append(kept, file)
+	}
+	return kept, skipped
+}
+
+// skipReason returns why a file is skipped, or an empty string if it is imported
+func (f *Filter) skipReason(file *ResolvedFile, largestVideo int64) string {
+	cfg := f.config
+	ext := strings.ToLower(filepath.Ext(file.RelPath))
+	relPath := filepath.ToSlash(file.RelPath)
+
+	if f.hasIncludeRules() && !f.included(ext, relPath) {
+		return "not matched by include rules"
+	}
+
+	for _, excluded := range cfg.ExcludeExtensions {
+		if ext == excluded {
+			return fmt.Sprintf("excluded extension %s", ext)
+		}
+	}
+	for _, pattern := range cfg.ExcludeGlobs {
+		if matchGlob(pattern, relPath) {
+			return fmt.Sprintf("excluded by glob %s", pattern)
+		}
+	}
+	if f.excludeRegex != nil && f.excludeRegex.MatchString(relPath) {
+		return fmt.Sprintf("excluded by regex %s", cfg.ExcludeRegex)
+	}
+
+	if cfg.MinSize > 0 && file.Size < cfg.MinSize {
+		return fmt.Sprintf("smaller than minimum size (%d < %d bytes)", file.Size, cfg.MinSize)
+	}
+
+	if cfg.SkipSamples && IsVideo(file.Name) && file.Size < largestVideo &&
+		float64(file.Size) < cfg.SampleRatio*float64(largestVideo) && samplePattern.MatchString(relPath) {
+		return "sample"
+	}
+
+	return ""
+}
+
+// hasIncludeRules reports whether any include rule is configured
+func (f *Filter) hasIncludeRules() bool {
+	return len(f.config.IncludeExtensions) > 0 || len(f.config.IncludeGlobs) > 0 || f.includeRegex != nil
+}
+
+// included reports whether a file matches any include rule
+func (f *Filter) included(ext, relPath string) bool {
+	for _, included := range f.config.IncludeExtensions {
+		if ext == included {
+			return true
+		}
+	}
+	for _, pattern := range f.config.IncludeGlobs {
+		if matchGlob(pattern, relPath) {
+			return true
+		}
+	}
+	return f.includeRegex != nil && f.includeRegex.MatchString(relPath)
+}
+
+// matchGlob matches a glob case-insensitively against a relative path and its base name
+func matchGlob(pattern, relPath string) bool {
+	pattern = strings.ToLower(pattern)
+	relPath = strings.ToLower(relPath)
+	if ok, _ := filepath.Match(pattern, relPath); ok {
+		return true
+	}
+	ok, _ := filepath.Match(pattern, filepath.Base(relPath))
+	return ok
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package files
+
+import "os"
+
+// linkCount returns the number of hard links to a file, if the platform reports it
+func linkCount(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
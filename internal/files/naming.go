@@ -0,0 +1,99 @@
+package files
+
+import (
+	"path/filepath"
+	"strings"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/qbit"
+	"qb-sync/internal/release"
+)
+
+// videoExtensions lists extensions treated as main media files
+var videoExtensions = map[string]bool{
+	".mkv": true, ".mp4": true, ".avi": true, ".m4v": true, ".mov": true, ".wmv": true,
+	".ts": true, ".m2ts": true, ".webm": true, ".mpg": true, ".mpeg": true,
+}
+
+// IsVideo reports whether a file name has a video extension
+func IsVideo(name string) bool {
+	return videoExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// MediaInfo parses release metadata for a file, filling fields missing from the
+// file name (common for season packs and short file names) from the torrent name
+func MediaInfo(torrent *qbit.Torrent, file *ResolvedFile) release.Info {
+	return mergeInfo(release.Parse(filepath.Base(file.RelPath)), release.Parse(torrent.Name))
+}
+
+// mergeInfo fills fields missing from info with the ones parsed from a parent name
+func mergeInfo(info, fallback release.Info) release.Info {
+	if info.Title == "" || (!info.IsEpisode && fallback.IsEpisode) {
+		info.Title = fallback.Title
+	}
+	if info.Year == 0 {
+		info.Year = fallback.Year
+	}
+	if !info.IsEpisode && fallback.IsEpisode {
+		info.IsEpisode = true
+		info.Season = fallback.Season
+		info.Episode = fallback.Episode
+	}
+	if info.IsEpisode && info.Season == 0 {
+		info.Season = fallback.Season
+	}
+	if info.Resolution == "" {
+		info.Resolution = fallback.Resolution
+	}
+	if info.Source == "" {
+		info.Source = fallback.Source
+	}
+	if info.Codec == "" {
+		info.Codec = fallback.Codec
+	}
+	if info.Group == "" {
+		info.Group = fallback.Group
+	}
+	return info
+}
+
+// namingTemplate returns the configured template for the kind of media
+func namingTemplate(cfg *config.MonitorConfig, info release.Info) string {
+	if info.IsEpisode {
+		return cfg.EpisodeTemplate
+	}
+	return cfg.MovieTemplate
+}
+
+// renderDestPath renders the naming template for a file relative to the destination.
+// Video files are renamed by the template; other files keep their relative path
+// inside the directory the template produces. It returns false if no template applies.
+func renderDestPath(cfg *config.MonitorConfig, torrent *qbit.Torrent, file *ResolvedFile) (string, bool, error) {
+	info := MediaInfo(torrent, file)
+	text := namingTemplate(cfg, info)
+	if text == "" {
+		return "", false, nil
+	}
+
+	tmpl, err := release.ParseTemplate(text)
+	if err != nil {
+		return "", false, err
+	}
+
+	ext := filepath.Ext(file.RelPath)
+	data := release.NameData{
+		Info:     info,
+		Ext:      ext,
+		Original: strings.TrimSuffix(filepath.Base(file.RelPath), ext),
+		Torrent:  torrent.Name,
+	}
+
+	rendered, err := release.Render(tmpl, data)
+	if err != nil {
+		return "", false, err
+	}
+	if IsVideo(file.Name) {
+		return rendered, true, nil
+	}
+	return filepath.Join(filepath.Dir(rendered), file.RelPath), true, nil
+}
@@ -2,85 +2,133 @@ package files
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"qb-sync/internal/config"
+	"qb-sync/internal/fsutil"
+	"qb-sync/internal/perms"
 	"qb-sync/internal/qbit"
 )
 
 // FileOperation represents the result of a file operation
 type FileOperation struct {
 	Source      string
+	Entry       string // Path inside the archive for extracted files
 	Destination string
 	Size        int64
+	Method      string
+	Decision    string // How an existing destination was handled, see the Decision constants
 	Success     bool
 	Error       error
 }
 
-// LinkOrCopy performs hardlink or copy operation based on configuration
-func LinkOrCopy(cfg *config.MonitorConfig, torrent *qbit.Torrent, file *qbit.TorrentFile) (*FileOperation, error) {
+// LinkOrCopy hardlinks or copies a file to its planned destination based on configuration.
+// An existing destination is handled according to the configured conflict policy.
+func LinkOrCopy(cfg *config.MonitorConfig, torrent *qbit.Torrent, file *ResolvedFile, destPath string) (*FileOperation, error) {
+	return linkOrCopy(cfg, torrent, file, destPath, false)
+}
+
+// linkOrCopy implements LinkOrCopy. With upgrade set, an existing destination is an older
+// release that is replaced regardless of the conflict policy.
+func linkOrCopy(cfg *config.MonitorConfig, torrent *qbit.Torrent, file *ResolvedFile, destPath string, upgrade bool) (*FileOperation, error) {
 	// Skip incomplete files
 	if strings.HasSuffix(file.Name, ".!qB") {
 		return nil, fmt.Errorf("skipping incomplete file: %s", file.Name)
 	}
 
-	// Build source and destination paths
-	// Use content_path as the base directory for files, not save_path
-	// content_path already includes the full path to where the files are located
-	sourcePath := filepath.Join(torrent.ContentPath, file.Name)
-	destPath, err := BuildDestPath(cfg, torrent, file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to build destination path: %w", err)
+	sourcePath := file.Source
+	result := &FileOperation{
+		Source:      sourcePath,
+		Destination: destPath,
+		Size:        file.Size,
+		Method:      cfg.Operation,
+	}
+
+	var conflict conflictResult
+	reason := "replaced at destination"
+	if _, statErr := os.Lstat(destPath); upgrade && statErr == nil {
+		conflict = conflictResult{Destination: destPath, Decision: DecisionReplaced, Write: true, Replace: true}
+		reason = upgradeReason
+	} else {
+		var err error
+		conflict, err = resolveConflict(cfg, destPath, file.Size, MediaInfo(torrent, file))
+		if err != nil {
+			result.Error = err
+			return result, err
+		}
 	}
-
-	// Check if destination already exists with same size (idempotency)
-	if info, err := os.Stat(destPath); err == nil && info.Size() == file.Size {
-		return &FileOperation{
-			Source:      sourcePath,
-			Destination: destPath,
-			Size:        file.Size,
-			Success:     true,
-		}, nil
+	result.Destination = conflict.Destination
+	result.Decision = conflict.Decision
+	if !conflict.Write {
+		result.Success = true
+		return result, nil
 	}
 
 	// Create destination directory
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return nil, fmt.Errorf("failed to create destination directory: %w", err)
+	if err := perms.MkdirAll(cfg, filepath.Dir(conflict.Destination)); err != nil {
+		result.Error = fmt.Errorf("failed to create destination directory: %w", err)
+		return result, result.Error
 	}
 
 	// Perform operation based on configuration
-	var opErr error
-	switch cfg.Operation {
-	case "hardlink":
-		opErr = createHardlink(sourcePath, destPath, cfg.CrossDeviceFallback, file.Size)
-	case "copy":
-		opErr = copyFile(sourcePath, destPath, file.Size)
-	default:
-		return nil, fmt.Errorf("unsupported operation: %s", cfg.Operation)
+	write := func(path string) error {
+		var err error
+		switch cfg.Operation {
+		case "hardlink":
+			err = createHardlink(sourcePath, path, cfg.CrossDeviceFallback, file.Size)
+		case "copy":
+			err = fsutil.CopyFile(sourcePath, path, file.Size)
+		default:
+			return fmt.Errorf("unsupported operation: %s", cfg.Operation)
+		}
+		if err != nil {
+			return err
+		}
+		return applyPermissions(cfg, sourcePath, path)
+	}
+	if conflict.Replace {
+		result.Error = writeReplacing(cfg, conflict.Destination, reason, write)
+	} else {
+		result.Error = write(conflict.Destination)
 	}
 
-	success := opErr == nil
-	return &FileOperation{
-		Source:      sourcePath,
-		Destination: destPath,
-		Size:        file.Size,
-		Success:     success,
-		Error:       opErr,
-	}, opErr
+	result.Success = result.Error == nil
+	return result, result.Error
+}
+
+// applyPermissions sets the configured mode and owner on a written file. Hardlinks share
+// their inode with the torrent data, so only their ownership is changed if they are symlinks.
+func applyPermissions(cfg *config.MonitorConfig, source, dest string) error {
+	sourceInfo, sourceErr := os.Lstat(source)
+	destInfo, destErr := os.Lstat(dest)
+	if sourceErr == nil && destErr == nil && os.SameFile(sourceInfo, destInfo) {
+		return perms.ApplySymlink(cfg, dest)
+	}
+	return perms.ApplyFile(cfg, dest)
 }
 
 // BuildDestPath constructs the destination path based on configuration
-func BuildDestPath(cfg *config.MonitorConfig, torrent *qbit.Torrent, file *qbit.TorrentFile) (string, error) {
+func BuildDestPath(cfg *config.MonitorConfig, torrent *qbit.Torrent, file *ResolvedFile) (string, error) {
+	// Naming templates take precedence over the subfolder settings
+	rendered, ok, err := renderDestPath(cfg, torrent, file)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return filepath.Join(cfg.DestPath, rendered), nil
+	}
+
+	// The layout follows the file name reported by qBittorrent, including any root folder,
+	// so existing libraries keep their structure. RelPath is only used to find sources.
+	name := filepath.Clean(filepath.FromSlash(file.Name))
 	if cfg.PreserveSubfolder {
 		// Preserve subfolder structure: dest_path/torrent_name/file_path
-		return filepath.Join(cfg.DestPath, torrent.Name, file.Name), nil
+		return filepath.Join(cfg.DestPath, torrent.Name, name), nil
 	}
 	// Flatten structure: dest_path/file_path
-	return filepath.Join(cfg.DestPath, file.Name), nil
+	return filepath.Join(cfg.DestPath, name), nil
 }
 
 // createHardlink attempts to create a hardlink, with fallback for cross-device errors
@@ -91,10 +139,10 @@ func createHardlink(src, dst, fallback string, expectedSize int64) error {
 	}
 
 	// Check if it's a cross-device error
-	if isCrossDeviceError(err) {
+	if fsutil.IsCrossDeviceError(err) {
 		switch fallback {
 		case "copy":
-			return copyFile(src, dst, expectedSize)
+			return fsutil.CopyFile(src, dst, expectedSize)
 		case "error":
 			return fmt.Errorf("cross-device hardlink not allowed: %w", err)
 		default:
@@ -105,66 +153,6 @@ func createHardlink(src, dst, fallback string, expectedSize int64) error {
 	return fmt.Errorf("failed to create hardlink: %w", err)
 }
 
-// copyFile copies a file with preservation of metadata
-func copyFile(src, dst string, expectedSize int64) error {
-	// Open source file
-	srcFile, err := os.Open(src)
-	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
-	}
-	defer srcFile.Close()
-
-	// Get source file info for metadata
-	srcInfo, err := srcFile.Stat()
-	if err != nil {
-		return fmt.Errorf("failed to stat source file: %w", err)
-	}
-
-	// Create destination file
-	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, srcInfo.Mode())
-	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
-	}
-	defer dstFile.Close()
-
-	// Copy file content
-	copied, err := io.Copy(dstFile, srcFile)
-	if err != nil {
-		return fmt.Errorf("failed to copy file content: %w", err)
-	}
-
-	// Verify size
-	if copied != expectedSize {
-		return fmt.Errorf("size mismatch: expected %d, got %d", expectedSize, copied)
-	}
-
-	// Sync to ensure data is written to disk
-	if err := dstFile.Sync(); err != nil {
-		return fmt.Errorf("failed to sync destination file: %w", err)
-	}
-
-	// Preserve modification time
-	if err := os.Chtimes(dst, time.Now(), srcInfo.ModTime()); err != nil {
-		return fmt.Errorf("failed to set modification time: %w", err)
-	}
-
-	return nil
-}
-
-// isCrossDeviceError checks if the error is a cross-device link error
-func isCrossDeviceError(err error) bool {
-	// On Unix systems, cross-device link errors have errno EXDEV (18)
-	// On Windows, they might have different error codes
-	if err == nil {
-		return false
-	}
-
-	errStr := err.Error()
-	return strings.Contains(errStr, "cross-device") ||
-		strings.Contains(errStr, "invalid cross-device link") ||
-		strings.Contains(errStr, "EXDEV")
-}
-
 // VerifyFileIntegrity checks if a file exists and has the expected size
 func VerifyFileIntegrity(path string, expectedSize int64) bool {
 	info, err := os.Stat(path)
@@ -173,11 +161,3 @@ func VerifyFileIntegrity(path string, expectedSize int64) bool {
 	}
 	return info.Size() == expectedSize
 }
-
-// CleanupDestination removes a file from the destination
-func CleanupDestination(path string) error {
-	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to cleanup destination file %s: %w", path, err)
-	}
-	return nil
-}
\ No newline at end of file
@@ -0,0 +1,163 @@
+package files
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"qb-sync/internal/config"
+)
+
+// maxDanglingRatio guards against removing every symlink when the mount behind them is down
+const maxDanglingRatio = 0.5
+
+// OrphanReport lists destination entries whose source no longer exists
+type OrphanReport struct {
+	DanglingSymlinks []string
+	UnlinkedFiles    []string // Imported files with a link count of 1 whose torrent is gone (only when hardlink checks are enabled)
+	EmptyDirs        []string
+	Symlinks         int // Total number of symlinks seen
+}
+
+// Count returns the number of orphaned entries
+func (r *OrphanReport) Count() int {
+	return len(r.DanglingSymlinks) + len(r.UnlinkedFiles) + len(r.EmptyDirs)
+}
+
+// MountSuspect reports whether so many symlinks dangle that the source mount is more
+// likely unavailable than the items expired; such a report must not be acted on
+func (r *OrphanReport) MountSuspect() bool {
+	return r.Symlinks > 1 && float64(len(r.DanglingSymlinks)) > maxDanglingRatio*float64(r.Symlinks)
+}
+
+// FindOrphans scans the destination for dangling symlinks, files whose torrent data is
+// gone and empty directories. The recycle and state directories are skipped.
+//
+// A file only counts as gone if hardlink checks are enabled, its link count is 1, it is
+// recorded in imports (destination -> torrent hash) and its torrent is not in torrents
+// (lowercase hashes). Media that was in the library before, or that qb-sync did not
+// import, is never reported.
+func FindOrphans(cfg *config.MonitorConfig, imports map[string]string, torrents map[string]bool) (*OrphanReport, error) {
+	report := &OrphanReport{}
+	root := filepath.Clean(cfg.DestPath)
+	recycleDir := filepath.Clean(cfg.RecycleDir)
+	stateDir := filepath.Clean(cfg.StateDir)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			if path == recycleDir || path == stateDir {
+				return filepath.SkipDir
+			}
+			if path != root {
+				if empty, err := isEmptyDir(path); err == nil && empty {
+					report.EmptyDirs = append(report.EmptyDirs, path)
+				}
+			}
+
+		case d.Type()&fs.ModeSymlink != 0:
+			report.Symlinks++
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				report.DanglingSymlinks = append(report.DanglingSymlinks, path)
+			}
+
+		case d.Type().IsRegular() && cfg.OrphanHardlinks:
+			hash, imported := imports[path]
+			if !imported || torrents[hash] || strings.HasSuffix(path, partialSuffix) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			if links, ok := linkCount(info); ok && links == 1 {
+				report.UnlinkedFiles = append(report.UnlinkedFiles, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("failed to scan destination: %w", err)
+	}
+	return report, nil
+}
+
+// RemoveOrphans moves orphaned files and symlinks to the recycle bin and removes
+// directories that are empty afterwards. It returns the number of removed entries.
+func RemoveOrphans(cfg *config.MonitorConfig, report *OrphanReport) (int, error) {
+	if report.MountSuspect() {
+		return 0, fmt.Errorf("%d of %d symlinks are dangling, the source mount is probably unavailable", len(report.DanglingSymlinks), report.Symlinks)
+	}
+
+	removed := 0
+	var errs []string
+	dirs := make(map[string]bool)
+	for _, dir := range report.EmptyDirs {
+		dirs[dir] = true
+	}
+
+	for _, path := range append(append([]string{}, report.DanglingSymlinks...), report.UnlinkedFiles...) {
+		if _, err := Recycle(cfg, path, "orphan"); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		removed++
+		dirs[filepath.Dir(path)] = true
+	}
+
+	// Remove the deepest directories first so their parents can become empty too
+	var pending []string
+	for dir := range dirs {
+		pending = append(pending, dir)
+	}
+	root := filepath.Clean(cfg.DestPath)
+	for len(pending) > 0 {
+		sort.Slice(pending, func(i, j int) bool { return len(pending[i]) > len(pending[j]) })
+		dir := pending[0]
+		pending = pending[1:]
+		if dir == root || !strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			continue
+		}
+		if empty, err := isEmptyDir(dir); err != nil || !empty {
+			continue
+		}
+		if err := os.Remove(dir); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		removed++
+		parent := filepath.Dir(dir)
+		if !dirs[parent] {
+			dirs[parent] = true
+			pending = append(pending, parent)
+		}
+	}
+
+	if len(errs) > 0 {
+		return removed, fmt.Errorf("failed to remove %d orphans: %s", len(errs), strings.Join(errs, "; "))
+	}
+	return removed, nil
+}
+
+// isEmptyDir reports whether a directory has no entries
+func isEmptyDir(path string) (bool, error) {
+	dir, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer dir.Close()
+
+	_, err = dir.Readdirnames(1)
+	if err == io.EOF {
+		return true, nil
+	}
+	return false, err
+}
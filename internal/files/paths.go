@@ -0,0 +1,96 @@
+package files
+
+import (
+	"path/filepath"
+	"strings"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/pathmap"
+	"qb-sync/internal/qbit"
+)
+
+// ResolvedFile is a torrent file with its location on disk resolved
+type ResolvedFile struct {
+	qbit.TorrentFile
+	Source  string // Absolute local path of the file
+	RelPath string // Path relative to the torrent content root, without any root folder
+}
+
+// ResolveFiles computes the source path of every file of a torrent.
+//
+// qBittorrent reports file names relative to save_path, including the root folder
+// (which may have been renamed) when the torrent has one. Emulators such as decypharr
+// report names relative to content_path instead. save_path, root_path, content_path and
+// the file list are combined to handle both, as well as single-file torrents where
+// content_path is the file itself and torrents added with "create subfolder" disabled.
+// Sources are translated from qBittorrent's view to the local one using the configured
+// path mappings.
+func ResolveFiles(cfg *config.MonitorConfig, torrent *qbit.Torrent, torrentFiles []qbit.TorrentFile) []ResolvedFile {
+	resolved := make([]ResolvedFile, 0, len(torrentFiles))
+	singleFile := len(torrentFiles) == 1
+	for _, file := range torrentFiles {
+		source, relPath := resolveFile(torrent, file.Name, singleFile)
+		resolved = append(resolved, ResolvedFile{
+			TorrentFile: file,
+			Source:      LocalPath(cfg, source),
+			RelPath:     relPath,
+		})
+	}
+	return resolved
+}
+
+// LocalPath translates a path reported by qBittorrent into the local path
+func LocalPath(cfg *config.MonitorConfig, remotePath string) string {
+	return pathmap.Map(cfg.PathMappings, remotePath)
+}
+
+// resolveFile returns the source path and root-relative path for a single file name
+func resolveFile(torrent *qbit.Torrent, name string, singleFile bool) (string, string) {
+	name = filepath.Clean(filepath.FromSlash(name))
+	contentPath := cleanOptional(torrent.ContentPath)
+	rootPath := cleanOptional(torrent.RootPath)
+	savePath := cleanOptional(torrent.SavePath)
+
+	root, rest := splitRoot(name)
+
+	switch {
+	// Single-file torrent: content_path is the file itself
+	case singleFile && rest == "" && contentPath != "" && filepath.Base(contentPath) == name:
+		return contentPath, name
+
+	// File name includes the (possibly renamed) root folder reported as root_path
+	case rest != "" && rootPath != "" && filepath.Base(rootPath) == root:
+		return filepath.Join(filepath.Dir(rootPath), name), rest
+
+	// File name includes the root folder, which is the last element of content_path
+	case rest != "" && contentPath != "" && filepath.Base(contentPath) == root:
+		return filepath.Join(filepath.Dir(contentPath), name), rest
+
+	// File name is relative to content_path (no root folder, or emulators)
+	case contentPath != "":
+		return filepath.Join(contentPath, name), name
+
+	// Nothing but save_path is known
+	default:
+		if rest != "" && root == torrent.Name {
+			return filepath.Join(savePath, name), rest
+		}
+		return filepath.Join(savePath, name), name
+	}
+}
+
+// splitRoot splits a relative path into its first element and the remainder
+func splitRoot(name string) (string, string) {
+	if i := strings.IndexRune(name, filepath.Separator); i != -1 {
+		return name[:i], name[i+1:]
+	}
+	return name, ""
+}
+
+// cleanOptional cleans a path, keeping empty paths empty
+func cleanOptional(path string) string {
+	if path == "" {
+		return ""
+	}
+	return filepath.Clean(path)
+}
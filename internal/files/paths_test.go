@@ -0,0 +1,139 @@
+package files
+
+import (
+	"path/filepath"
+	"testing"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/pathmap"
+	"qb-sync/internal/qbit"
+)
+
+func TestResolveFiles(t *testing.T) {
+	type want struct {
+		source  string
+		relPath string
+	}
+
+	tests := []struct {
+		name     string
+		torrent  qbit.Torrent
+		files    []string
+		mappings []pathmap.Rule
+		want     []want
+	}{
+		{
+			name:    "single file",
+			torrent: qbit.Torrent{Name: "Movie.mkv", SavePath: "/dl", ContentPath: "/dl/Movie.mkv"},
+			files:   []string{"Movie.mkv"},
+			want:    []want{{"/dl/Movie.mkv", "Movie.mkv"}},
+		},
+		{
+			name:    "single file in a subfolder",
+			torrent: qbit.Torrent{Name: "Movie", SavePath: "/dl", RootPath: "/dl/Movie", ContentPath: "/dl/Movie/Movie.mkv"},
+			files:   []string{"Movie/Movie.mkv"},
+			want:    []want{{"/dl/Movie/Movie.mkv", "Movie.mkv"}},
+		},
+		{
+			name:    "multi file with root_path",
+			torrent: qbit.Torrent{Name: "Show", SavePath: "/dl", RootPath: "/dl/Show", ContentPath: "/dl/Show"},
+			files:   []string{"Show/e1.mkv", "Show/Subs/e1.srt"},
+			want:    []want{{"/dl/Show/e1.mkv", "e1.mkv"}, {"/dl/Show/Subs/e1.srt", "Subs/e1.srt"}},
+		},
+		{
+			name:    "renamed root folder with root_path",
+			torrent: qbit.Torrent{Name: "Show", SavePath: "/dl", RootPath: "/dl/Show.Renamed", ContentPath: "/dl/Show.Renamed"},
+			files:   []string{"Show.Renamed/e1.mkv", "Show.Renamed/e2.mkv"},
+			want:    []want{{"/dl/Show.Renamed/e1.mkv", "e1.mkv"}, {"/dl/Show.Renamed/e2.mkv", "e2.mkv"}},
+		},
+		{
+			name:    "renamed root folder without root_path",
+			torrent: qbit.Torrent{Name: "Show", SavePath: "/dl", ContentPath: "/dl/Show.Renamed"},
+			files:   []string{"Show.Renamed/e1.mkv", "Show.Renamed/e2.mkv"},
+			want:    []want{{"/dl/Show.Renamed/e1.mkv", "e1.mkv"}, {"/dl/Show.Renamed/e2.mkv", "e2.mkv"}},
+		},
+		{
+			name:    "create subfolder disabled",
+			torrent: qbit.Torrent{Name: "Show", SavePath: "/dl", ContentPath: "/dl"},
+			files:   []string{"e1.mkv", "Subs/e1.srt"},
+			want:    []want{{"/dl/e1.mkv", "e1.mkv"}, {"/dl/Subs/e1.srt", "Subs/e1.srt"}},
+		},
+		{
+			name:    "names relative to content_path (emulators)",
+			torrent: qbit.Torrent{Name: "Movie", SavePath: "/mnt/dl", ContentPath: "/mnt/dl/Movie"},
+			files:   []string{"Movie.mkv", "Subs/en.srt"},
+			want:    []want{{"/mnt/dl/Movie/Movie.mkv", "Movie.mkv"}, {"/mnt/dl/Movie/Subs/en.srt", "Subs/en.srt"}},
+		},
+		{
+			name:    "only save_path, multi file",
+			torrent: qbit.Torrent{Name: "Show", SavePath: "/dl"},
+			files:   []string{"Show/e1.mkv", "Show/e2.mkv"},
+			want:    []want{{"/dl/Show/e1.mkv", "e1.mkv"}, {"/dl/Show/e2.mkv", "e2.mkv"}},
+		},
+		{
+			name:    "only save_path, single file",
+			torrent: qbit.Torrent{Name: "Movie.mkv", SavePath: "/dl"},
+			files:   []string{"Movie.mkv"},
+			want:    []want{{"/dl/Movie.mkv", "Movie.mkv"}},
+		},
+		{
+			name:     "path mapping",
+			torrent:  qbit.Torrent{Name: "Show", SavePath: "/downloads", RootPath: "/downloads/Show", ContentPath: "/downloads/Show"},
+			files:    []string{"Show/e1.mkv", "Show/e2.mkv"},
+			mappings: []pathmap.Rule{{From: "/downloads", To: "/mnt/torrents"}},
+			want:     []want{{"/mnt/torrents/Show/e1.mkv", "e1.mkv"}, {"/mnt/torrents/Show/e2.mkv", "e2.mkv"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.MonitorConfig{PathMappings: tt.mappings}
+			var torrentFiles []qbit.TorrentFile
+			for _, name := range tt.files {
+				torrentFiles = append(torrentFiles, qbit.TorrentFile{Name: name})
+			}
+
+			resolved := ResolveFiles(cfg, &tt.torrent, torrentFiles)
+			if len(resolved) != len(tt.want) {
+				t.Fatalf("got %d files, want %d", len(resolved), len(tt.want))
+			}
+			for i, file := range resolved {
+				if file.Source != filepath.FromSlash(tt.want[i].source) {
+					t.Errorf("%s: source = %s, want %s", file.Name, file.Source, tt.want[i].source)
+				}
+				if file.RelPath != filepath.FromSlash(tt.want[i].relPath) {
+					t.Errorf("%s: relPath = %s, want %s", file.Name, file.RelPath, tt.want[i].relPath)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildDestPathKeepsLayout(t *testing.T) {
+	torrent := &qbit.Torrent{Name: "Show", SavePath: "/dl", RootPath: "/dl/Show", ContentPath: "/dl/Show"}
+	files := ResolveFiles(&config.MonitorConfig{}, torrent, []qbit.TorrentFile{{Name: "Show/e1.mkv"}, {Name: "Show/Subs/e1.srt"}})
+
+	tests := []struct {
+		name     string
+		preserve bool
+		want     []string
+	}{
+		{"flatten", false, []string{"/media/Show/e1.mkv", "/media/Show/Subs/e1.srt"}},
+		{"preserve subfolder", true, []string{"/media/Show/Show/e1.mkv", "/media/Show/Show/Subs/e1.srt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.MonitorConfig{DestPath: "/media", PreserveSubfolder: tt.preserve}
+			for i := range files {
+				got, err := BuildDestPath(cfg, torrent, &files[i])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != filepath.FromSlash(tt.want[i]) {
+					t.Errorf("%s: destination = %s, want %s", files[i].Name, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
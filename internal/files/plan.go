@@ -0,0 +1,128 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/qbit"
+)
+
+// Operation methods recorded for planned and performed operations
+const (
+	MethodHardlink = "hardlink"
+	MethodCopy     = "copy"
+	MethodExtract  = "extract"
+	MethodRecycle  = "recycle"
+)
+
+// PlannedOp is a single operation planned for a torrent
+type PlannedOp struct {
+	Method      string
+	File        *ResolvedFile // File to link or copy
+	Archive     *ArchiveSet   // Archive to extract
+	Source      string
+	Destination string   // Destination file, or destination directory for extraction
+	FileCount   int      // Number of torrent files covered by the operation
+	Replaces    []string // Existing files of an older release moved to the recycle bin after the operation
+}
+
+// BuildPlan decides how each file of a torrent is imported without touching the destination
+func BuildPlan(cfg *config.MonitorConfig, torrent *qbit.Torrent, torrentFiles []ResolvedFile) ([]PlannedOp, error) {
+	remaining := torrentFiles
+	var plan []PlannedOp
+
+	if cfg.ExtractArchives {
+		var archives []*ArchiveSet
+		archives, remaining = DetectArchives(torrentFiles)
+		for _, archive := range archives {
+			destPath, err := BuildDestPath(cfg, torrent, &archive.First)
+			if err != nil {
+				return nil, fmt.Errorf("failed to build destination path for %s: %w", archive.First.Name, err)
+			}
+			plan = append(plan, PlannedOp{
+				Method:      MethodExtract,
+				Archive:     archive,
+				Source:      archive.First.Source,
+				Destination: filepath.Dir(destPath),
+				FileCount:   len(archive.Volumes),
+			})
+		}
+	}
+
+	for i := range remaining {
+		file := &remaining[i]
+		destPath, err := BuildDestPath(cfg, torrent, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build destination path for %s: %w", file.Name, err)
+		}
+		plan = append(plan, PlannedOp{
+			Method:      cfg.Operation,
+			File:        file,
+			Source:      file.Source,
+			Destination: destPath,
+			FileCount:   1,
+		})
+	}
+
+	if cfg.RenameSubtitles || cfg.PlaceExtras {
+		placeCompanions(cfg, torrent, plan)
+	}
+	if cfg.UpgradeMode {
+		planUpgrades(cfg, torrent, plan)
+	}
+
+	return plan, nil
+}
+
+// ExecuteOp performs a planned operation and returns the resulting file operations.
+// Files replaced by the operation are recycled once it succeeded.
+func ExecuteOp(cfg *config.MonitorConfig, torrent *qbit.Torrent, op *PlannedOp) ([]*FileOperation, error) {
+	var results []*FileOperation
+
+	// A replaced file at the new destination is recycled only once the new file was written next to it
+	upgrade := false
+	for _, old := range op.Replaces {
+		upgrade = upgrade || old == op.Destination
+	}
+
+	switch op.Method {
+	case MethodExtract:
+		extracted, err := ExtractArchive(cfg, torrent, op.Archive)
+		return append(results, extracted...), err
+	default:
+		result, err := linkOrCopy(cfg, torrent, op.File, op.Destination, upgrade)
+		if result != nil {
+			results = append(results, result)
+		}
+		if err != nil || result.Decision == DecisionSkipped {
+			return results, err
+		}
+	}
+
+	for _, old := range op.Replaces {
+		if old != op.Destination {
+			result := recycleFile(cfg, old)
+			results = append(results, result)
+			if result.Error != nil {
+				return results, result.Error
+			}
+		}
+	}
+	return results, nil
+}
+
+// upgradeReason is recorded for files recycled because a better release replaced them
+const upgradeReason = "replaced by a higher quality release"
+
+// recycleFile moves a replaced file to the recycle bin and records the operation
+func recycleFile(cfg *config.MonitorConfig, path string) *FileOperation {
+	result := &FileOperation{Source: path, Method: MethodRecycle, Decision: DecisionRecycled}
+	if info, err := os.Stat(path); err == nil {
+		result.Size = info.Size()
+	}
+	result.Destination, result.Error = Recycle(cfg, path, upgradeReason)
+	result.Success = result.Error == nil
+	return result
+}
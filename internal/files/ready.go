@@ -0,0 +1,39 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MissingSource describes a source file that is not available yet
+type MissingSource struct {
+	Path  string
+	Error error
+}
+
+// CheckSourcesReady stats every source file of a torrent and returns the ones
+// that are missing or do not have the expected size yet
+func CheckSourcesReady(torrentFiles []ResolvedFile) []MissingSource {
+	var missing []MissingSource
+	for i := range torrentFiles {
+		file := &torrentFiles[i]
+		if strings.HasSuffix(file.Name, ".!qB") {
+			continue
+		}
+
+		sourcePath := file.Source
+		info, err := os.Stat(sourcePath)
+		if err != nil {
+			missing = append(missing, MissingSource{Path: sourcePath, Error: err})
+			continue
+		}
+		if info.Size() != file.Size {
+			missing = append(missing, MissingSource{
+				Path:  sourcePath,
+				Error: fmt.Errorf("size mismatch: expected %d, got %d", file.Size, info.Size()),
+			})
+		}
+	}
+	return missing
+}
@@ -0,0 +1,18 @@
+package files
+
+import (
+	"path/filepath"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/recycle"
+)
+
+// Recycle moves a file out of the destination into the recycle bin instead of
+// deleting it, and returns its new location
+func Recycle(cfg *config.MonitorConfig, path, reason string) (string, error) {
+	entry, err := recycle.New(cfg).Move(path, reason)
+	if entry == nil {
+		return "", err
+	}
+	return filepath.Join(cfg.RecycleDir, filepath.FromSlash(entry.ID)), err
+}
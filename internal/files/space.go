@@ -0,0 +1,120 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"qb-sync/internal/config"
+)
+
+// SpaceShortage describes a destination filesystem without enough free space for a plan
+type SpaceShortage struct {
+	Path      string // Destination directory on the filesystem
+	Needed    int64
+	Available int64 // Free space minus the configured reserve, never negative
+}
+
+// CheckSpace sums the bytes a plan writes per destination filesystem and returns the
+// filesystems where they exceed the free space minus the reserve. Hardlinks need no
+// space unless they fall back to copies across devices; files already in place are ignored.
+func CheckSpace(cfg *config.MonitorConfig, plan []PlannedOp) ([]SpaceShortage, error) {
+	type filesystem struct {
+		path   string
+		needed int64
+	}
+	filesystems := make(map[uint64]*filesystem)
+
+	for i := range plan {
+		op := &plan[i]
+		needed := spaceNeeded(cfg, op)
+		if needed == 0 {
+			continue
+		}
+
+		dir := existingAncestor(filepath.Dir(op.Destination))
+		device, ok := deviceID(dir)
+		if !ok {
+			// Free space cannot be checked on this platform
+			return nil, nil
+		}
+		fs, ok := filesystems[device]
+		if !ok {
+			fs = &filesystem{path: dir}
+			filesystems[device] = fs
+		}
+		fs.needed += needed
+	}
+
+	var shortages []SpaceShortage
+	for _, fs := range filesystems {
+		free, ok, err := freeSpace(fs.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check free space of %s: %w", fs.path, err)
+		}
+		if !ok {
+			return nil, nil
+		}
+		// A reserve larger than the free space leaves nothing, not a negative amount
+		available := max(free-cfg.DiskReserve, 0)
+		if fs.needed > available {
+			shortages = append(shortages, SpaceShortage{Path: fs.path, Needed: fs.needed, Available: available})
+		}
+	}
+	sort.Slice(shortages, func(i, j int) bool { return shortages[i].Path < shortages[j].Path })
+	return shortages, nil
+}
+
+// FormatSize formats a byte size with binary units, e.g. "1.5 GiB"
+func FormatSize(bytes int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	value := float64(bytes)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
+
+// spaceNeeded returns the bytes an operation writes to the destination filesystem
+func spaceNeeded(cfg *config.MonitorConfig, op *PlannedOp) int64 {
+	switch op.Method {
+	case MethodExtract:
+		// Scene archives are mostly stored, so the volumes approximate the extracted size
+		return op.Archive.Size()
+	case MethodCopy:
+		if VerifyFileIntegrity(op.Destination, op.File.Size) {
+			return 0
+		}
+		return op.File.Size
+	case MethodHardlink:
+		if cfg.CrossDeviceFallback != "copy" || VerifyFileIntegrity(op.Destination, op.File.Size) {
+			return 0
+		}
+		source, okSource := deviceID(op.Source)
+		dest, okDest := deviceID(existingAncestor(filepath.Dir(op.Destination)))
+		if okSource && okDest && source != dest {
+			return op.File.Size
+		}
+	}
+	return 0
+}
+
+// existingAncestor returns the closest existing directory of path
+func existingAncestor(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd)
+
+package files
+
+// freeSpace returns the bytes available on the filesystem holding path, if the platform reports it
+func freeSpace(path string) (int64, bool, error) {
+	return 0, false, nil
+}
+
+// deviceID returns the ID of the device holding a file, if the platform reports it
+func deviceID(path string) (uint64, bool) {
+	return 0, false
+}
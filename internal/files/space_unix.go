@@ -0,0 +1,30 @@
+//go:build linux || darwin || freebsd
+
+package files
+
+import (
+	"os"
+	"syscall"
+)
+
+// freeSpace returns the bytes available to unprivileged users on the filesystem holding path
+func freeSpace(path string) (int64, bool, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, false, err
+	}
+	return int64(uint64(stat.Bavail) * uint64(stat.Bsize)), true, nil
+}
+
+// deviceID returns the ID of the device holding a file, without following symlinks
+func deviceID(path string) (uint64, bool) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return 0, false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
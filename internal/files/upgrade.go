@@ -0,0 +1,151 @@
+package files
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/qbit"
+	"qb-sync/internal/release"
+)
+
+// titleKeyPattern matches characters ignored when comparing titles
+var titleKeyPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// planUpgrades marks existing media of the same movie or episode that the planned
+// videos replace because they rank higher in the quality profile.
+// Archive contents are unknown at planning time and never replace existing media.
+func planUpgrades(cfg *config.MonitorConfig, torrent *qbit.Torrent, plan []PlannedOp) {
+	planned := make(map[string]bool)
+	for i := range plan {
+		planned[plan[i].Destination] = true
+	}
+
+	for i := range plan {
+		op := &plan[i]
+		if op.File == nil || !IsVideo(op.File.Name) || isExtra(op.File.RelPath) {
+			continue
+		}
+
+		info := MediaInfo(torrent, op.File)
+		if info.Title == "" {
+			continue
+		}
+
+		for _, existing := range findExistingMedia(cfg, op.Destination, info) {
+			// Unknown quality is never replaced, and a file of the same size is this release imported earlier
+			current := existingInfo(existing)
+			if !cfg.QualityProfile.Known(current) || VerifyFileIntegrity(existing, op.File.Size) ||
+				cfg.QualityProfile.Compare(info, current) <= 0 {
+				continue
+			}
+			op.Replaces = append(op.Replaces, existing)
+			for _, companion := range subtitleCompanions(existing) {
+				if !planned[companion] {
+					op.Replaces = append(op.Replaces, companion)
+				}
+			}
+		}
+	}
+}
+
+// findExistingMedia returns videos in the destination holding the same movie or episode.
+// It looks next to the planned destination and, for layouts without naming templates,
+// in the top-level entries of the destination whose name matches the title.
+func findExistingMedia(cfg *config.MonitorConfig, destPath string, info release.Info) []string {
+	seen := make(map[string]bool)
+	var found []string
+	consider := func(path string) {
+		rel, err := filepath.Rel(cfg.DestPath, path)
+		if err != nil {
+			rel = filepath.Base(path)
+		}
+		if seen[path] || !IsVideo(path) || isExtra(rel) {
+			return
+		}
+		seen[path] = true
+		if sameMedia(info, existingInfo(path)) {
+			found = append(found, path)
+		}
+	}
+
+	if entries, err := os.ReadDir(filepath.Dir(destPath)); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				consider(filepath.Join(filepath.Dir(destPath), entry.Name()))
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(cfg.DestPath)
+	if err != nil {
+		return found
+	}
+	for _, entry := range entries {
+		// Hidden entries include the recycle directory
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(cfg.DestPath, entry.Name())
+		if !entry.IsDir() {
+			consider(path)
+			continue
+		}
+		if titleKey(release.Parse(entry.Name()).Title) != titleKey(info.Title) {
+			continue
+		}
+		filepath.WalkDir(path, func(walked string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				consider(walked)
+			}
+			return nil
+		})
+	}
+	return found
+}
+
+// existingInfo parses release metadata of a file already in the destination,
+// using its folder name when the file name carries no title
+func existingInfo(path string) release.Info {
+	info := release.Parse(filepath.Base(path))
+	if info.Title == "" {
+		info = mergeInfo(info, release.Parse(filepath.Base(filepath.Dir(path))))
+	}
+	return info
+}
+
+// sameMedia reports whether two releases hold the same movie or episode
+func sameMedia(a, b release.Info) bool {
+	if titleKey(a.Title) == "" || titleKey(a.Title) != titleKey(b.Title) || a.IsEpisode != b.IsEpisode {
+		return false
+	}
+	if a.IsEpisode {
+		return a.Season == b.Season && a.Episode == b.Episode
+	}
+	return a.Year == 0 || b.Year == 0 || a.Year == b.Year
+}
+
+// titleKey normalizes a title for comparison
+func titleKey(title string) string {
+	return titleKeyPattern.ReplaceAllString(strings.ToLower(title), "")
+}
+
+// subtitleCompanions returns subtitles next to a video that are named after it
+func subtitleCompanions(videoPath string) []string {
+	prefix := strings.TrimSuffix(filepath.Base(videoPath), filepath.Ext(videoPath)) + "."
+	entries, err := os.ReadDir(filepath.Dir(videoPath))
+	if err != nil {
+		return nil
+	}
+
+	var companions []string
+	for _, entry := range entries {
+		if !entry.IsDir() && IsSubtitle(entry.Name()) && strings.HasPrefix(entry.Name(), prefix) {
+			companions = append(companions, filepath.Join(filepath.Dir(videoPath), entry.Name()))
+		}
+	}
+	return companions
+}
@@ -0,0 +1,90 @@
+// Package fsutil holds the file helpers shared by the import and recycle bin code
+package fsutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FreeName returns the first unused name of the form "name (N).ext".
+// A taken name is returned with exists set when reuse reports that it already
+// holds the wanted file; reuse may be nil.
+func FreeName(path string, reuse func(candidate string, info os.FileInfo) bool) (string, bool, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for n := 1; n < 1000; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+		info, err := os.Lstat(candidate)
+		if os.IsNotExist(err) {
+			return candidate, false, nil
+		}
+		if err == nil && reuse != nil && reuse(candidate, info) {
+			return candidate, true, nil
+		}
+	}
+	return "", false, fmt.Errorf("no free name found for %s", path)
+}
+
+// CopyFile copies a file with preservation of metadata, verifying the copied size
+func CopyFile(src, dst string, expectedSize int64) error {
+	// Open source file
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open source file: %w", err)
+	}
+	defer srcFile.Close()
+
+	// Get source file info for metadata
+	srcInfo, err := srcFile.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat source file: %w", err)
+	}
+
+	// Create destination file
+	dstFile, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, srcInfo.Mode())
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %w", err)
+	}
+	defer dstFile.Close()
+
+	// Copy file content
+	copied, err := io.Copy(dstFile, srcFile)
+	if err != nil {
+		return fmt.Errorf("failed to copy file content: %w", err)
+	}
+
+	// Verify size
+	if copied != expectedSize {
+		return fmt.Errorf("size mismatch: expected %d, got %d", expectedSize, copied)
+	}
+
+	// Sync to ensure data is written to disk
+	if err := dstFile.Sync(); err != nil {
+		return fmt.Errorf("failed to sync destination file: %w", err)
+	}
+
+	// Preserve modification time
+	if err := os.Chtimes(dst, time.Now(), srcInfo.ModTime()); err != nil {
+		return fmt.Errorf("failed to set modification time: %w", err)
+	}
+
+	return nil
+}
+
+// IsCrossDeviceError checks if the error is a cross-device link or rename error
+func IsCrossDeviceError(err error) bool {
+	// On Unix systems, cross-device link errors have errno EXDEV (18)
+	// On Windows, they might have different error codes
+	if err == nil {
+		return false
+	}
+
+	errStr := err.Error()
+	return strings.Contains(errStr, "cross-device") ||
+		strings.Contains(errStr, "invalid cross-device link") ||
+		strings.Contains(errStr, "EXDEV")
+}
@@ -0,0 +1,73 @@
+package pathmap
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Rule maps paths below one root to another root
+type Rule struct {
+	From string
+	To   string
+}
+
+// Parse parses a comma separated list of "from=to" rules, keeping their order
+func Parse(spec string) ([]Rule, error) {
+	var rules []Rule
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		from, to, ok := strings.Cut(entry, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid path mapping %q, expected from=to", entry)
+		}
+
+		rules = append(rules, Rule{From: filepath.Clean(from), To: cleanRoot(to)})
+	}
+	return rules, nil
+}
+
+// Map rewrites a path using the first rule whose root contains it.
+// Paths that match no rule are returned unchanged.
+func Map(rules []Rule, path string) string {
+	if path == "" {
+		return path
+	}
+	clean := filepath.Clean(path)
+	for _, rule := range rules {
+		if rest, ok := below(rule.From, clean); ok {
+			if rule.To == "/" {
+				return filepath.Join(rule.To, rest)
+			}
+			return rule.To + rest
+		}
+	}
+	return path
+}
+
+// below reports whether path is root or inside root and returns the remainder
+func below(root, path string) (string, bool) {
+	if path == root {
+		return "", true
+	}
+	if root == "/" {
+		return path, true
+	}
+	if strings.HasPrefix(path, root+"/") {
+		return path[len(root):], true
+	}
+	return "", false
+}
+
+// cleanRoot normalizes a rule root, dropping trailing slashes except for "/"
+func cleanRoot(root string) string {
+	if root == "/" {
+		return root
+	}
+	return strings.TrimRight(root, "/")
+}
@@ -0,0 +1,71 @@
+package pathmap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []Rule
+		wantErr bool
+	}{
+		{spec: "", want: nil},
+		{spec: "/downloads=/mnt/torrents", want: []Rule{{From: "/downloads", To: "/mnt/torrents"}}},
+		{spec: "/downloads/=/mnt/torrents/", want: []Rule{{From: "/downloads", To: "/mnt/torrents"}}},
+		{spec: " /a = /b , , /c=/", want: []Rule{{From: "/a", To: "/b"}, {From: "/c", To: "/"}}},
+		{spec: "/data/movies=/m,/data=/d", want: []Rule{{From: "/data/movies", To: "/m"}, {From: "/data", To: "/d"}}},
+		{spec: "/downloads", wantErr: true},
+		{spec: "=/mnt/torrents", wantErr: true},
+		{spec: "/downloads=", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := Parse(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, want error %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMap(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		path  string
+		want  string
+	}{
+		{"below the root", "/data=/mnt/data", "/data/Movie/Movie.mkv", "/mnt/data/Movie/Movie.mkv"},
+		{"exact root", "/data=/mnt/data", "/data", "/mnt/data"},
+		{"sibling prefix", "/data=/mnt/data", "/data2/Movie.mkv", "/data2/Movie.mkv"},
+		{"sibling prefix of a longer root", "/data2=/mnt/data2", "/data/Movie.mkv", "/data/Movie.mkv"},
+		{"trailing slash in the path", "/data=/mnt/data", "/data/Movie/", "/mnt/data/Movie"},
+		{"trailing slashes in the rule", "/data/=/mnt/data/", "/data/Movie.mkv", "/mnt/data/Movie.mkv"},
+		{"unmatched path is unchanged", "/data=/mnt/data", "/other//Movie.mkv", "/other//Movie.mkv"},
+		{"empty path", "/data=/mnt/data", "", ""},
+		{"first match wins, specific first", "/data/movies=/m,/data=/d", "/data/movies/Movie.mkv", "/m/Movie.mkv"},
+		{"first match wins, general first", "/data=/d,/data/movies=/m", "/data/movies/Movie.mkv", "/d/movies/Movie.mkv"},
+		{"later rule when the first does not match", "/data/movies=/m,/data=/d", "/data/tv/e1.mkv", "/d/tv/e1.mkv"},
+		{"from filesystem root", "/=/mnt/remote", "/data/Movie.mkv", "/mnt/remote/data/Movie.mkv"},
+		{"to filesystem root", "/data=/", "/data/Movie.mkv", "/Movie.mkv"},
+		{"exact root to filesystem root", "/data=/", "/data", "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := Parse(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Map(rules, tt.path); got != tt.want {
+				t.Errorf("Map(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package perms
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"qb-sync/internal/config"
+)
+
+// MkdirAll creates a directory and its missing parents, applying the configured
+// directory mode and ownership to the directories it creates. Without a directory
+// mode they get 0777 reduced by the configured umask, like files copied by qb-sync.
+func MkdirAll(cfg *config.MonitorConfig, dir string) error {
+	var missing []string
+	for path := filepath.Clean(dir); ; path = filepath.Dir(path) {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		missing = append(missing, path)
+		if filepath.Dir(path) == path {
+			break
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	mode := dirMode(cfg)
+	if err := os.MkdirAll(dir, mode); err != nil {
+		return err
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		// Chmod explicitly, the mode passed to MkdirAll is reduced by the process umask,
+		// which is not the configured one in every code path
+		if cfg.DirMode != 0 || cfg.Umask >= 0 {
+			if err := os.Chmod(missing[i], mode); err != nil {
+				return fmt.Errorf("failed to set mode of %s: %w", missing[i], err)
+			}
+		}
+		if err := chown(cfg, missing[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dirMode returns the mode of created directories: the configured one, or 0777
+// reduced by the configured umask. Without either the process umask applies.
+func dirMode(cfg *config.MonitorConfig) os.FileMode {
+	if cfg.DirMode != 0 {
+		return cfg.DirMode
+	}
+	if cfg.Umask >= 0 {
+		return 0o777 &^ os.FileMode(cfg.Umask)
+	}
+	return 0o777
+}
+
+// ApplyFile applies the configured file mode and ownership to a file written by qb-sync.
+// Symlinks only get their ownership changed, their mode cannot be set.
+func ApplyFile(cfg *config.MonitorConfig, path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 && cfg.FileMode != 0 {
+		if err := os.Chmod(path, cfg.FileMode); err != nil {
+			return fmt.Errorf("failed to set mode of %s: %w", path, err)
+		}
+	}
+	return chown(cfg, path)
+}
+
+// ApplySymlink applies the configured ownership to a symlink, leaving regular files untouched.
+// Hardlinks share their inode with the torrent data, so only symlinks are changed.
+func ApplySymlink(cfg *config.MonitorConfig, path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+	return chown(cfg, path)
+}
+
+// chown sets the configured owner and group without following symlinks
+func chown(cfg *config.MonitorConfig, path string) error {
+	if cfg.UID == -1 && cfg.GID == -1 {
+		return nil
+	}
+	if err := os.Lchown(path, cfg.UID, cfg.GID); err != nil {
+		return fmt.Errorf("failed to set owner of %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+//go:build unix
+
+package perms
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"qb-sync/internal/config"
+)
+
+func TestMkdirAllMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		dirMode os.FileMode
+		umask   int
+		want    os.FileMode
+	}{
+		{"umask without dir mode", 0, 0o002, 0o775},
+		{"restrictive umask without dir mode", 0, 0o027, 0o750},
+		{"dir mode wins over umask", 0o700, 0o002, 0o700},
+		{"dir mode without umask", 0o750, -1, 0o750},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			cfg := &config.MonitorConfig{DirMode: tt.dirMode, Umask: tt.umask, UID: -1, GID: -1}
+			if err := MkdirAll(cfg, filepath.Join(root, "Show", "Season 01")); err != nil {
+				t.Fatal(err)
+			}
+			for _, dir := range []string{"Show", filepath.Join("Show", "Season 01")} {
+				info, err := os.Stat(filepath.Join(root, dir))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := info.Mode().Perm(); got != tt.want {
+					t.Errorf("%s: mode = %o, want %o", dir, got, tt.want)
+				}
+			}
+		})
+	}
+}
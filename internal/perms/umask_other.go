@@ -0,0 +1,8 @@
+//go:build !unix
+
+package perms
+
+import "qb-sync/internal/config"
+
+// ApplyUmask sets the process umask if one is configured; it is not supported on this platform
+func ApplyUmask(cfg *config.MonitorConfig) {}
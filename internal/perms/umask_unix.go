@@ -0,0 +1,16 @@
+//go:build unix
+
+package perms
+
+import (
+	"syscall"
+
+	"qb-sync/internal/config"
+)
+
+// ApplyUmask sets the process umask if one is configured
+func ApplyUmask(cfg *config.MonitorConfig) {
+	if cfg.Umask >= 0 {
+		syscall.Umask(cfg.Umask)
+	}
+}
@@ -0,0 +1,163 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the version of the plan format
+const Version = 1
+
+// Plan formats
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// Action kinds performed on a torrent after its files were imported
+const (
+	ActionDeleteTorrent = "delete-torrent"
+	ActionKeepTorrent   = "keep-torrent"
+)
+
+// Plan lists what a dry run would do, for review before it is applied
+type Plan struct {
+	Version     int       `json:"version" yaml:"version"`
+	CreatedAt   time.Time `json:"created_at" yaml:"created_at"`
+	Category    string    `json:"category" yaml:"category"`
+	Destination string    `json:"destination" yaml:"destination"`
+	Operation   string    `json:"operation" yaml:"operation"`
+	Conflict    string    `json:"conflict_policy" yaml:"conflict_policy"`
+	Torrents    []Torrent `json:"torrents" yaml:"torrents"`
+}
+
+// Torrent is the planned import of one torrent
+type Torrent struct {
+	Hash        string    `json:"hash" yaml:"hash"`
+	Name        string    `json:"name" yaml:"name"`
+	Category    string    `json:"category" yaml:"category"`
+	ContentPath string    `json:"content_path" yaml:"content_path"`
+	Steps       []Step    `json:"steps" yaml:"steps"`
+	Filtered    []Skipped `json:"filtered,omitempty" yaml:"filtered,omitempty"`
+	PlexRefresh []string  `json:"plex_refresh,omitempty" yaml:"plex_refresh,omitempty"` // directories refreshed in Plex
+	Actions     []Action  `json:"actions,omitempty" yaml:"actions,omitempty"`
+	Warnings    []string  `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+// Step is a planned file operation
+type Step struct {
+	Method      string   `json:"method" yaml:"method"`
+	Source      string   `json:"source" yaml:"source"`
+	Size        int64    `json:"size" yaml:"size"` // size of the source, or of all volumes for extraction
+	Destination string   `json:"destination" yaml:"destination"`
+	Decision    string   `json:"decision,omitempty" yaml:"decision,omitempty"` // expected conflict decision, empty for extraction
+	Replaces    []string `json:"replaces,omitempty" yaml:"replaces,omitempty"`
+	Error       string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Skipped is a torrent file excluded by the filters
+type Skipped struct {
+	Name   string `json:"name" yaml:"name"`
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// Action is done to the torrent in qBittorrent once all steps succeeded
+type Action struct {
+	Kind        string `json:"kind" yaml:"kind"`
+	DeleteFiles bool   `json:"delete_files,omitempty" yaml:"delete_files,omitempty"`
+}
+
+// Sort orders torrents by name so plans of the same state compare equal
+func (p *Plan) Sort() {
+	sort.SliceStable(p.Torrents, func(i, j int) bool {
+		return strings.ToLower(p.Torrents[i].Name) < strings.ToLower(p.Torrents[j].Name)
+	})
+}
+
+// FormatFor returns the format for a plan file: the given format, or the one its extension implies
+func FormatFor(path, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatYAML, "yml":
+		return FormatYAML, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unknown plan format: %s (use json or yaml)", format)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	default:
+		return FormatJSON, nil
+	}
+}
+
+// Encode serializes a plan in the given format
+func Encode(p *Plan, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case FormatYAML:
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(p); err != nil {
+			return nil, fmt.Errorf("failed to encode plan: %w", err)
+		}
+		enc.Close()
+	default:
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(p); err != nil {
+			return nil, fmt.Errorf("failed to encode plan: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteFile writes a plan atomically, so a reader never sees a partial plan
+func WriteFile(path, format string, p *Plan) error {
+	data, err := Encode(p, format)
+	if err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write plan: %w", err)
+	}
+	return nil
+}
+
+// ReadFile reads a plan in the given format
+func ReadFile(path, format string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	var p Plan
+	switch format {
+	case FormatYAML:
+		err = yaml.Unmarshal(data, &p)
+	default:
+		err = json.Unmarshal(data, &p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode plan: %w", err)
+	}
+	if p.Version != Version {
+		return nil, fmt.Errorf("unsupported plan version %d (expected %d)", p.Version, Version)
+	}
+	return &p, nil
+}
@@ -12,20 +12,21 @@ import (
 	"time"
 
 	"qb-sync/internal/config"
+	"qb-sync/internal/pathmap"
 )
 
 // Library represents a Plex library section
 type Library struct {
-	Key       string    `xml:"key,attr"`
-	Type      string    `xml:"type,attr"`
-	Title     string    `xml:"title,attr"`
-	Locations []Location `xml:"Location"`
+	Key       string    `xml:"key,attr" json:"key"`
+	Type      string    `xml:"type,attr" json:"type"`
+	Title     string    `xml:"title,attr" json:"title"`
+	Locations []Location `xml:"Location" json:"locations"`
 }
 
 // Location represents a library location path
 type Location struct {
-	ID   int    `xml:"id,attr"`
-	Path string `xml:"path,attr"`
+	ID   int    `xml:"id,attr" json:"id"`
+	Path string `xml:"path,attr" json:"path"`
 }
 
 // MediaContainer represents the root XML element in Plex API responses
@@ -36,9 +37,10 @@ type MediaContainer struct {
 
 // Client represents a Plex Media Server client
 type Client struct {
-	httpClient *http.Client
-	baseURL    *url.URL
-	token      string
+	httpClient   *http.Client
+	baseURL      *url.URL
+	token        string
+	pathMappings []pathmap.Rule
 }
 
 // NewClient creates a new Plex client
@@ -58,9 +60,10 @@ func NewClient(cfg *config.PlexConfig) (*Client, error) {
 	}
 
 	return &Client{
-		httpClient: httpClient,
-		baseURL:    baseURL,
-		token:      cfg.Token,
+		httpClient:   httpClient,
+		baseURL:      baseURL,
+		token:        cfg.Token,
+		pathMappings: cfg.PathMappings,
 	}, nil
 }
 
@@ -183,8 +186,25 @@ func (c *Client) RefreshLibraryPath(ctx context.Context, libraryKey, path string
 	return nil
 }
 
-// RefreshPathForFile finds the appropriate library and refreshes the specific path containing the file
+// RefreshPath finds the library containing a local directory and refreshes that directory.
+// The local path is translated to Plex's view using the configured path mappings.
+func (c *Client) RefreshPath(ctx context.Context, dirPath string) error {
+	dirPath = pathmap.Map(c.pathMappings, dirPath)
+
+	library, _, err := c.FindLibraryByPath(ctx, dirPath)
+	if err != nil {
+		return fmt.Errorf("failed to find library for path: %w", err)
+	}
+	log.Printf("Found library '%s' (ID: %s) for path: %s", library.Title, library.Key, dirPath)
+
+	return c.RefreshLibraryPath(ctx, library.Key, dirPath)
+}
+
+// RefreshPathForFile finds the appropriate library and refreshes the specific path containing the file.
+// The local file path is translated to Plex's view using the configured path mappings.
 func (c *Client) RefreshPathForFile(ctx context.Context, filePath string) error {
+	filePath = pathmap.Map(c.pathMappings, filePath)
+
 	library, _, err := c.FindLibraryByPath(ctx, filePath)
 	if err != nil {
 		return fmt.Errorf("failed to find library for file: %w", err)
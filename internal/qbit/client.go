@@ -15,21 +15,32 @@ import (
 	"time"
 
 	"qb-sync/internal/config"
-	"qb-sync/internal/telegram"
 )
 
 // Torrent represents a torrent from qBittorrent
 type Torrent struct {
 	Hash         string  `json:"hash"`
 	Name         string  `json:"name"`
-	State        string  `json:"state"`
+	State        State   `json:"state"`
 	Progress     float64 `json:"progress"`
 	Category     string  `json:"category"`
 	SavePath     string  `json:"save_path"`
+	RootPath     string  `json:"root_path"`
 	ContentPath  string  `json:"content_path"`
 	Size         int64   `json:"size"`
 	Completed    int64   `json:"completed"`
 	CompletionOn int64  `json:"completion_on"`
+	Tags         string  `json:"tags"`
+}
+
+// HasTag reports whether the torrent carries the given tag
+func (t *Torrent) HasTag(tag string) bool {
+	for _, existing := range strings.Split(t.Tags, ",") {
+		if strings.TrimSpace(existing) == tag {
+			return true
+		}
+	}
+	return false
 }
 
 // TorrentFile represents a file within a torrent
@@ -46,6 +57,7 @@ type Client struct {
 	httpClient *http.Client
 	baseURL    *url.URL
 	config     *config.QBConfig
+	version    Version
 }
 
 // NewClient creates a new qBittorrent client
@@ -155,6 +167,11 @@ func (c *Client) ListAllTorrents(ctx context.Context) ([]Torrent, error) {
 		return nil, fmt.Errorf("failed to decode torrent list: %w", err)
 	}
 
+	// Normalize version specific state names
+	for i := range torrents {
+		torrents[i].State = NormalizeState(string(torrents[i].State))
+	}
+
 	return torrents, nil
 }
 
@@ -166,14 +183,28 @@ func FilterCompletedTorrents(torrents []Torrent, category string) []Torrent {
 		if category != "" && t.Category != category {
 			continue
 		}
-		// Filter for truly completed torrents (progress == 1.0 and not in transitional states)
-		if t.Progress == 1.0 && !isTransitionalState(t.State) {
+		// Filter for truly completed torrents (progress == 1.0 and not in transitional or error states)
+		if t.Progress == 1.0 && !t.State.IsTransitional() && !t.State.IsError() {
 			completed = append(completed, t)
 		}
 	}
 	return completed
 }
 
+// FilterBrokenTorrents filters torrents in an error or missingFiles state in a specific category
+func FilterBrokenTorrents(torrents []Torrent, category string) []Torrent {
+	var broken []Torrent
+	for _, t := range torrents {
+		if category != "" && t.Category != category {
+			continue
+		}
+		if t.State.IsError() {
+			broken = append(broken, t)
+		}
+	}
+	return broken
+}
+
 // ListCompletedByCategory retrieves completed torrents for a specific category (legacy method)
 func (c *Client) ListCompletedByCategory(ctx context.Context, category string) ([]Torrent, error) {
 	torrents, err := c.ListAllTorrents(ctx)
@@ -249,18 +280,87 @@ func (c *Client) DeleteTorrent(ctx context.Context, hash string, deleteFiles boo
 	return nil
 }
 
-// isTransitionalState checks if a torrent is in a transitional state
-func isTransitionalState(state string) bool {
-	transitionalStates := []string{
-		"checkingDL", "checkingUP", "checkingResumeData",
-		"moving", "metaDL", "allocating",
+// ResumeTorrents resumes (starts) torrents using the endpoint supported by the server
+func (c *Client) ResumeTorrents(ctx context.Context, hashes ...string) error {
+	endpoint := "/api/v2/torrents/resume"
+	if c.version.UsesStopStart() {
+		endpoint = "/api/v2/torrents/start"
 	}
-	for _, s := range transitionalStates {
-		if state == s {
-			return true
-		}
+	return c.postHashes(ctx, endpoint, hashes)
+}
+
+// RecheckTorrents asks qBittorrent to recheck the data of torrents
+func (c *Client) RecheckTorrents(ctx context.Context, hashes ...string) error {
+	return c.postHashes(ctx, "/api/v2/torrents/recheck", hashes)
+}
+
+// ReannounceTorrents asks qBittorrent to reannounce torrents to their trackers
+func (c *Client) ReannounceTorrents(ctx context.Context, hashes ...string) error {
+	return c.postHashes(ctx, "/api/v2/torrents/reannounce", hashes)
+}
+
+// AddTags adds tags to torrents
+func (c *Client) AddTags(ctx context.Context, hashes []string, tags ...string) error {
+	tagsURL := c.baseURL.ResolveReference(&url.URL{
+		Path: "/api/v2/torrents/addTags",
+	})
+
+	// Prepare form data
+	data := fmt.Sprintf("hashes=%s&tags=%s",
+		url.QueryEscape(strings.Join(hashes, "|")),
+		url.QueryEscape(strings.Join(tags, ",")))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", tagsURL.String(), strings.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create add tags request: %w", err)
 	}
-	return false
+
+	// Set required headers
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Referer", c.baseURL.String())
+	req.Header.Set("Origin", c.baseURL.String())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to perform add tags request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("add tags failed with status: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// postHashes posts a hashes form to a torrent action endpoint
+func (c *Client) postHashes(ctx context.Context, endpoint string, hashes []string) error {
+	actionURL := c.baseURL.ResolveReference(&url.URL{Path: endpoint})
+
+	// Prepare form data
+	data := fmt.Sprintf("hashes=%s", url.QueryEscape(strings.Join(hashes, "|")))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", actionURL.String(), strings.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", endpoint, err)
+	}
+
+	// Set required headers
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Referer", c.baseURL.String())
+	req.Header.Set("Origin", c.baseURL.String())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to perform %s request: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s failed with status: %s", endpoint, resp.Status)
+	}
+
+	return nil
 }
 
 // AddTorrentFromMagnet adds a torrent from a magnet link
@@ -345,32 +445,6 @@ func (c *Client) AddTorrentFromFile(ctx context.Context, fileURL, category strin
 	return nil
 }
 
-// GetAllTorrents implements the QBClient interface for Telegram bot
-func (c *Client) GetAllTorrents(ctx context.Context) ([]telegram.TorrentInfo, error) {
-	torrents, err := c.ListAllTorrents(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	// Convert to Telegram bot format
-	var torrentInfos []telegram.TorrentInfo
-	for _, t := range torrents {
-		torrentInfos = append(torrentInfos, telegram.TorrentInfo{
-			Name:     t.Name,
-			Category: t.Category,
-			State:    t.State,
-			Hash:     t.Hash,
-		})
-	}
-
-	return torrentInfos, nil
-}
-
-// AddTorrent implements the QBClient interface for Telegram bot
-func (c *Client) AddTorrent(ctx context.Context, magnetLink, category string) error {
-	return c.AddTorrentFromMagnet(ctx, magnetLink, category)
-}
-
 // decodeJSON is a helper function to decode JSON response
 func decodeJSON(r io.Reader, v interface{}) error {
 	decoder := json.NewDecoder(r)
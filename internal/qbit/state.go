@@ -0,0 +1,146 @@
+package qbit
+
+// State is a torrent state normalized across qBittorrent versions.
+// qBittorrent 5.x renamed the paused states to stopped; both map to the
+// same internal value so callers never have to care which server they talk to.
+type State string
+
+// Internal torrent states, covering qBittorrent 4.x and 5.x
+const (
+	StateError              State = "error"
+	StateMissingFiles       State = "missingFiles"
+	StateUploading          State = "uploading"
+	StatePausedUP           State = "pausedUP" // stoppedUP in 5.x
+	StateQueuedUP           State = "queuedUP"
+	StateStalledUP          State = "stalledUP"
+	StateCheckingUP         State = "checkingUP"
+	StateForcedUP           State = "forcedUP"
+	StateAllocating         State = "allocating"
+	StateDownloading        State = "downloading"
+	StateMetaDL             State = "metaDL"
+	StateForcedMetaDL       State = "forcedMetaDL"
+	StatePausedDL           State = "pausedDL" // stoppedDL in 5.x
+	StateQueuedDL           State = "queuedDL"
+	StateStalledDL          State = "stalledDL"
+	StateCheckingDL         State = "checkingDL"
+	StateForcedDL           State = "forcedDL"
+	StateCheckingResumeData State = "checkingResumeData"
+	StateMoving             State = "moving"
+	StateUnknown            State = "unknown"
+
+	// StateCompleted is not reported by qBittorrent itself but by some emulators
+	StateCompleted State = "completed"
+)
+
+// AllStates lists every known state in display order
+var AllStates = []State{
+	StateDownloading, StateForcedDL, StateMetaDL, StateForcedMetaDL, StateStalledDL,
+	StateQueuedDL, StatePausedDL, StateAllocating, StateCheckingDL, StateCheckingUP,
+	StateCheckingResumeData, StateMoving, StateUploading, StateForcedUP, StateStalledUP,
+	StateQueuedUP, StatePausedUP, StateCompleted, StateError, StateMissingFiles, StateUnknown,
+}
+
+// stateNames maps states to user-friendly names
+var stateNames = map[State]string{
+	StateError:              "Error",
+	StateMissingFiles:       "Missing Files",
+	StateUploading:          "Uploading",
+	StatePausedUP:           "Paused (Uploading)",
+	StateQueuedUP:           "Queued (Uploading)",
+	StateStalledUP:          "Stalled (Uploading)",
+	StateCheckingUP:         "Checking (Uploading)",
+	StateForcedUP:           "Forced (Uploading)",
+	StateAllocating:         "Allocating",
+	StateDownloading:        "Downloading",
+	StateMetaDL:             "Downloading Metadata",
+	StateForcedMetaDL:       "Forced (Downloading Metadata)",
+	StatePausedDL:           "Paused (Downloading)",
+	StateQueuedDL:           "Queued (Downloading)",
+	StateStalledDL:          "Stalled (Downloading)",
+	StateCheckingDL:         "Checking (Downloading)",
+	StateForcedDL:           "Forced (Downloading)",
+	StateCheckingResumeData: "Checking Resume Data",
+	StateMoving:             "Moving",
+	StateUnknown:            "Unknown",
+	StateCompleted:          "Completed",
+}
+
+// stateAliases maps state names reported by other qBittorrent versions to internal states
+var stateAliases = map[string]State{
+	"stoppedUP": StatePausedUP,
+	"stoppedDL": StatePausedDL,
+}
+
+// NormalizeState converts a state reported by the server into the internal enum
+func NormalizeState(raw string) State {
+	if state, ok := stateAliases[raw]; ok {
+		return state
+	}
+	if raw == "" {
+		return StateUnknown
+	}
+	return State(raw)
+}
+
+// DisplayName returns a user-friendly name for the state
+func (s State) DisplayName() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return string(s)
+}
+
+// IsKnown reports whether the state is one of the known states
+func (s State) IsKnown() bool {
+	_, ok := stateNames[s]
+	return ok
+}
+
+// IsComplete reports whether all wanted data is present (upload-side states)
+func (s State) IsComplete() bool {
+	switch s {
+	case StateUploading, StatePausedUP, StateQueuedUP, StateStalledUP,
+		StateCheckingUP, StateForcedUP, StateCompleted:
+		return true
+	}
+	return false
+}
+
+// IsTransitional reports whether the torrent is being checked, moved or prepared
+func (s State) IsTransitional() bool {
+	switch s {
+	case StateCheckingDL, StateCheckingUP, StateCheckingResumeData,
+		StateMoving, StateMetaDL, StateForcedMetaDL, StateAllocating:
+		return true
+	}
+	return false
+}
+
+// IsError reports whether the torrent is in an error state
+func (s State) IsError() bool {
+	return s == StateError || s == StateMissingFiles
+}
+
+// IsPaused reports whether the torrent is paused (stopped in 5.x)
+func (s State) IsPaused() bool {
+	return s == StatePausedUP || s == StatePausedDL
+}
+
+// IsSeeding reports whether the torrent is complete and actively seeding
+func (s State) IsSeeding() bool {
+	switch s {
+	case StateUploading, StateStalledUP, StateForcedUP:
+		return true
+	}
+	return false
+}
+
+// IsDownloading reports whether the torrent is still downloading or waiting to
+func (s State) IsDownloading() bool {
+	switch s {
+	case StateDownloading, StateForcedDL, StateMetaDL, StateForcedMetaDL,
+		StateStalledDL, StateQueuedDL:
+		return true
+	}
+	return false
+}
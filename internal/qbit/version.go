@@ -0,0 +1,147 @@
+package qbit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// webAPIStopStart is the first WebAPI version (qBittorrent 5.0) that renamed
+// the pause/resume endpoints to stop/start
+var webAPIStopStart = [3]int{2, 11, 0}
+
+// Version describes the qBittorrent (or emulator) instance the client talks to
+type Version struct {
+	App      string // Application version, e.g. "v5.0.2"
+	WebAPI   string // WebAPI version, e.g. "2.11.2"
+	Detected bool   // False when the server did not report its versions
+	webAPI   [3]int
+}
+
+// String returns a human readable description of the version
+func (v Version) String() string {
+	if !v.Detected {
+		return "unknown (assuming qBittorrent 4.x API)"
+	}
+	app := v.App
+	if app == "" {
+		app = "unknown"
+	}
+	return fmt.Sprintf("app %s, WebAPI %s", app, v.WebAPI)
+}
+
+// AtLeast reports whether the WebAPI version is at least major.minor.patch
+func (v Version) AtLeast(major, minor, patch int) bool {
+	want := [3]int{major, minor, patch}
+	for i := range want {
+		if v.webAPI[i] != want[i] {
+			return v.webAPI[i] > want[i]
+		}
+	}
+	return true
+}
+
+// UsesStopStart reports whether the server expects the 5.x stop/start endpoints
+func (v Version) UsesStopStart() bool {
+	return v.Detected && v.AtLeast(webAPIStopStart[0], webAPIStopStart[1], webAPIStopStart[2])
+}
+
+// DetectVersion queries the application and WebAPI versions and stores them on the client.
+// Servers that do not implement the version endpoints (e.g. some emulators) are treated
+// as qBittorrent 4.x; only transport errors are returned.
+func (c *Client) DetectVersion(ctx context.Context) (Version, error) {
+	var version Version
+
+	webAPI, status, err := c.getText(ctx, "/api/v2/app/webapiVersion")
+	if err != nil {
+		return version, fmt.Errorf("failed to query WebAPI version: %w", err)
+	}
+	if status == http.StatusOK {
+		if parsed, ok := parseVersion(webAPI); ok {
+			version.WebAPI = webAPI
+			version.webAPI = parsed
+			version.Detected = true
+		}
+	}
+
+	app, status, err := c.getText(ctx, "/api/v2/app/version")
+	if err != nil {
+		return version, fmt.Errorf("failed to query application version: %w", err)
+	}
+	if status == http.StatusOK {
+		version.App = app
+	}
+
+	c.version = version
+	return version, nil
+}
+
+// Version returns the version detected by DetectVersion
+func (c *Client) Version() Version {
+	return c.version
+}
+
+// DefaultSavePath returns qBittorrent's default save path
+func (c *Client) DefaultSavePath(ctx context.Context) (string, error) {
+	path, status, err := c.getText(ctx, "/api/v2/app/defaultSavePath")
+	if err != nil {
+		return "", fmt.Errorf("failed to get default save path: %w", err)
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("failed to get default save path: status %d", status)
+	}
+	return path, nil
+}
+
+// getText performs a GET request and returns the trimmed body and status code
+func (c *Client) getText(ctx context.Context, path string) (string, int, error) {
+	reqURL := c.baseURL.ResolveReference(&url.URL{Path: path})
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set required headers
+	req.Header.Set("Referer", c.baseURL.String())
+	req.Header.Set("Origin", c.baseURL.String())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to perform request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", resp.StatusCode, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return strings.TrimSpace(string(body)), resp.StatusCode, nil
+}
+
+// parseVersion parses a dotted version such as "2.11.2" or "v5.0.0"
+func parseVersion(s string) ([3]int, bool) {
+	var parsed [3]int
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if s == "" {
+		return parsed, false
+	}
+	parts := strings.SplitN(s, ".", 3)
+	for i, part := range parts {
+		// Drop suffixes such as "-beta1"
+		if end := strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' }); end != -1 {
+			part = part[:end]
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return parsed, false
+		}
+		parsed[i] = n
+	}
+	return parsed, true
+}
@@ -0,0 +1,137 @@
+package rclone
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"qb-sync/internal/config"
+)
+
+// Client represents an rclone remote control (RC) client
+type Client struct {
+	httpClient *http.Client
+	baseURL    *url.URL
+	username   string
+	password   string
+	mountPath  string
+}
+
+// rcError represents an error returned by the rclone RC API
+type rcError struct {
+	Error  string `json:"error"`
+	Status int    `json:"status"`
+}
+
+// NewClient creates a new rclone RC client
+func NewClient(cfg *config.RcloneConfig) (*Client, error) {
+	baseURL, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rclone RC URL: %w", err)
+	}
+
+	httpClient := &http.Client{
+		Timeout: 60 * time.Second,
+	}
+
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+		username:   cfg.Username,
+		password:   cfg.Password,
+		mountPath:  filepath.Clean(cfg.MountPath),
+	}, nil
+}
+
+// RemotePath converts a local path below the mount into a path relative to the VFS root.
+// It returns false if the path is not below the mount.
+func (c *Client) RemotePath(localPath string) (string, bool) {
+	rel, err := filepath.Rel(c.mountPath, filepath.Clean(localPath))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if rel == "." {
+		rel = ""
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// RefreshDir refreshes the VFS directory cache for a local directory below the mount
+func (c *Client) RefreshDir(ctx context.Context, localDir string, recursive bool) error {
+	dir, ok := c.RemotePath(localDir)
+	if !ok {
+		return fmt.Errorf("path %s is not below rclone mount %s", localDir, c.mountPath)
+	}
+
+	log.Printf("Refreshing rclone VFS directory: %s (recursive: %t)", dir, recursive)
+
+	params := map[string]interface{}{}
+	if dir != "" {
+		params["dir"] = dir
+	}
+	if recursive {
+		params["recursive"] = "true"
+	}
+	return c.call(ctx, "vfs/refresh", params)
+}
+
+// Forget drops a local file or directory below the mount from the VFS directory cache
+func (c *Client) Forget(ctx context.Context, localPath string, isDir bool) error {
+	remotePath, ok := c.RemotePath(localPath)
+	if !ok {
+		return fmt.Errorf("path %s is not below rclone mount %s", localPath, c.mountPath)
+	}
+	if remotePath == "" {
+		return fmt.Errorf("refusing to forget the mount root %s", c.mountPath)
+	}
+
+	log.Printf("Forgetting rclone VFS path: %s", remotePath)
+
+	key := "file"
+	if isDir {
+		key = "dir"
+	}
+	return c.call(ctx, "vfs/forget", map[string]interface{}{key: remotePath})
+}
+
+// call performs an RC call with JSON parameters
+func (c *Client) call(ctx context.Context, command string, params map[string]interface{}) error {
+	callURL := c.baseURL.ResolveReference(&url.URL{Path: "/" + command})
+
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s parameters: %w", command, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", callURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s request: %w", command, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to perform %s request: %w", command, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var rcErr rcError
+		if err := json.NewDecoder(resp.Body).Decode(&rcErr); err == nil && rcErr.Error != "" {
+			return fmt.Errorf("%s failed with status %s: %s", command, resp.Status, rcErr.Error)
+		}
+		return fmt.Errorf("%s failed with status: %s", command, resp.Status)
+	}
+
+	return nil
+}
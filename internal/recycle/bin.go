@@ -0,0 +1,310 @@
+package recycle
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/fsutil"
+	"qb-sync/internal/perms"
+)
+
+const (
+	// dateLayout names the dated directories of the recycle bin
+	dateLayout = "2006-01-02"
+	// manifestName is the manifest file inside each dated directory
+	manifestName = "manifest.json"
+	// filesDir holds the recycled files inside each dated directory
+	filesDir = "files"
+)
+
+// manifestMu serializes manifest updates within the process
+var manifestMu sync.Mutex
+
+// Entry describes a recycled file
+type Entry struct {
+	ID         string    `json:"id"`       // Path of the recycled file relative to the recycle directory
+	Original   string    `json:"original"` // Path the file was recycled from
+	Size       int64     `json:"size"`
+	Reason     string    `json:"reason"`
+	RecycledAt time.Time `json:"recycled_at"`
+}
+
+// Bin moves files out of the destination into dated directories with a JSON manifest
+type Bin struct {
+	cfg       *config.MonitorConfig
+	dir       string
+	destPath  string
+	retention time.Duration
+}
+
+// New creates a recycle bin from configuration
+func New(cfg *config.MonitorConfig) *Bin {
+	return &Bin{
+		cfg:       cfg,
+		dir:       cfg.RecycleDir,
+		destPath:  cfg.DestPath,
+		retention: cfg.RecycleRetention,
+	}
+}
+
+// Dir returns the recycle directory
+func (b *Bin) Dir() string {
+	return b.dir
+}
+
+// Move moves a file or symlink into today's recycle directory and records it in the manifest
+func (b *Bin) Move(path, reason string) (*Entry, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("refusing to recycle directory %s", path)
+	}
+
+	// Keep the path relative to the destination so recycled files are easy to recognize
+	rel, err := filepath.Rel(b.destPath, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		rel = filepath.Base(path)
+	}
+
+	now := time.Now()
+	day := now.Format(dateLayout)
+	target, err := freePath(filepath.Join(b.dir, day, filesDir, rel))
+	if err != nil {
+		return nil, err
+	}
+	if err := perms.MkdirAll(b.cfg, filepath.Dir(target)); err != nil {
+		return nil, fmt.Errorf("failed to create recycle directory: %w", err)
+	}
+	if err := move(path, target, info); err != nil {
+		return nil, fmt.Errorf("failed to move %s to recycle bin: %w", path, err)
+	}
+
+	id, _ := filepath.Rel(b.dir, target)
+	entry := &Entry{
+		ID:         filepath.ToSlash(id),
+		Original:   path,
+		Size:       info.Size(),
+		Reason:     reason,
+		RecycledAt: now,
+	}
+
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+	entries, err := readManifest(filepath.Join(b.dir, day))
+	if err != nil {
+		return entry, err
+	}
+	if err := b.writeManifest(filepath.Join(b.dir, day), append(entries, *entry)); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// List returns all recycled files, oldest first
+func (b *Bin) List() ([]Entry, error) {
+	days, err := b.days()
+	if err != nil {
+		return nil, err
+	}
+
+	var all []Entry
+	for _, day := range days {
+		entries, err := readManifest(filepath.Join(b.dir, day))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, entries...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].RecycledAt.Before(all[j].RecycledAt)
+	})
+	return all, nil
+}
+
+// Find returns the entries matching an ID or original path.
+// For an original path recycled more than once, the most recent entry comes first.
+func (b *Bin) Find(key string) ([]Entry, error) {
+	entries, err := b.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].ID == filepath.ToSlash(key) || entries[i].Original == key {
+			matches = append(matches, entries[i])
+		}
+	}
+	return matches, nil
+}
+
+// Restore moves a recycled file back to its original path.
+// An existing file at the original path is recycled first when force is set.
+func (b *Bin) Restore(entry Entry, force bool) error {
+	stored := filepath.Join(b.dir, filepath.FromSlash(entry.ID))
+	info, err := os.Lstat(stored)
+	if err != nil {
+		return fmt.Errorf("recycled file %s is missing: %w", entry.ID, err)
+	}
+
+	if _, err := os.Lstat(entry.Original); err == nil {
+		if !force {
+			return fmt.Errorf("%s already exists", entry.Original)
+		}
+		if _, err := b.Move(entry.Original, "replaced by restore"); err != nil {
+			return err
+		}
+	}
+
+	if err := perms.MkdirAll(b.cfg, filepath.Dir(entry.Original)); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := move(stored, entry.Original, info); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", entry.Original, err)
+	}
+
+	// Drop the entry from its manifest
+	day := strings.SplitN(entry.ID, "/", 2)[0]
+	manifestMu.Lock()
+	defer manifestMu.Unlock()
+	entries, err := readManifest(filepath.Join(b.dir, day))
+	if err != nil {
+		return err
+	}
+	kept := entries[:0]
+	for _, existing := range entries {
+		if existing.ID != entry.ID {
+			kept = append(kept, existing)
+		}
+	}
+	return b.writeManifest(filepath.Join(b.dir, day), kept)
+}
+
+// Purge removes dated directories whose files are all older than the retention period.
+// A zero retention keeps recycled files forever.
+func (b *Bin) Purge(now time.Time) (int, error) {
+	if b.retention <= 0 {
+		return 0, nil
+	}
+	days, err := b.days()
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	cutoff := now.Add(-b.retention)
+	for _, day := range days {
+		date, _ := time.ParseInLocation(dateLayout, day, time.Local)
+		if !date.AddDate(0, 0, 1).Before(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(b.dir, day)); err != nil {
+			return purged, fmt.Errorf("failed to purge %s: %w", day, err)
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// days returns the dated directories of the recycle bin in ascending order
+func (b *Bin) days() ([]string, error) {
+	entries, err := os.ReadDir(b.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recycle directory: %w", err)
+	}
+
+	var days []string
+	for _, entry := range entries {
+		if _, err := time.Parse(dateLayout, entry.Name()); err == nil && entry.IsDir() {
+			days = append(days, entry.Name())
+		}
+	}
+	sort.Strings(days)
+	return days, nil
+}
+
+// readManifest reads the manifest of a dated directory
+func readManifest(dayDir string) ([]Entry, error) {
+	data, err := os.ReadFile(filepath.Join(dayDir, manifestName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recycle manifest: %w", err)
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse recycle manifest %s: %w", filepath.Join(dayDir, manifestName), err)
+	}
+	return entries, nil
+}
+
+// writeManifest replaces the manifest of a dated directory
+func (b *Bin) writeManifest(dayDir string, entries []Entry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recycle manifest: %w", err)
+	}
+	if err := perms.MkdirAll(b.cfg, dayDir); err != nil {
+		return fmt.Errorf("failed to create recycle directory: %w", err)
+	}
+
+	tmpPath := filepath.Join(dayDir, manifestName+".tmp")
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write recycle manifest: %w", err)
+	}
+	if err := perms.ApplyFile(b.cfg, tmpPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, filepath.Join(dayDir, manifestName)); err != nil {
+		return fmt.Errorf("failed to write recycle manifest: %w", err)
+	}
+	return nil
+}
+
+// freePath returns path, or "name (N).ext" if path is taken
+func freePath(path string) (string, error) {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return path, nil
+	}
+	free, _, err := fsutil.FreeName(path, nil)
+	return free, err
+}
+
+// move renames a file, copying it when source and target are on different filesystems
+func move(src, dst string, info os.FileInfo) error {
+	err := os.Rename(src, dst)
+	if err == nil || !fsutil.IsCrossDeviceError(err) {
+		return err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		if err := os.Symlink(target, dst); err != nil {
+			return err
+		}
+		return os.Remove(src)
+	}
+
+	if err := fsutil.CopyFile(src, dst, info.Size()); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,207 @@
+package release
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Info holds metadata parsed from a release name
+type Info struct {
+	Title      string
+	Year       int
+	Season     int
+	Episode    int
+	Resolution string // e.g. 2160p, 1080p
+	Source     string // e.g. BluRay, WEB-DL, HDTV
+	Codec      string // e.g. x265, x264, AV1
+	Group      string
+	IsEpisode  bool // True when a season or episode marker was found
+}
+
+var (
+	episodePattern    = regexp.MustCompile(`(?i)\bS(\d{1,2})[ ._-]?E(\d{1,3})(?:[ ._-]?E\d{1,3})*\b`)
+	crossEpPattern    = regexp.MustCompile(`(?i)\b(\d{1,2})x(\d{2,3})\b`)
+	seasonPattern     = regexp.MustCompile(`(?i)\b(?:S(\d{1,2})|Season[ ._-]?(\d{1,2}))\b`)
+	yearPattern       = regexp.MustCompile(`\b(19\d{2}|20\d{2})\b`)
+	resolutionPattern = regexp.MustCompile(`(?i)\b(4320p|2160p|1440p|1080[pi]|720p|576[pi]|480[pi]|4k|uhd)\b`)
+	sourcePattern     = regexp.MustCompile(`(?i)\b(remux|blu[ -]?ray|bdrip|brrip|web[ -]?dl|webrip|web|hdtv|dvdrip|dvd|hdrip|cam|telesync)\b`)
+	codecPattern      = regexp.MustCompile(`(?i)\b([xh][ .]?26[45]|hevc|avc|av1|xvid|divx|vp9)\b`)
+	remuxPattern      = regexp.MustCompile(`(?i)\bremux\b`)
+	groupPattern      = regexp.MustCompile(`-([A-Za-z0-9]+)(?:\[[^\]]*\])?$`)
+	bracketPattern    = regexp.MustCompile(`\[[^\]]*\]|\([^)]*\)`)
+	spacePattern      = regexp.MustCompile(`\s+`)
+)
+
+// mediaExtensions are stripped from names before parsing
+var mediaExtensions = map[string]bool{
+	".mkv": true, ".mp4": true, ".avi": true, ".m4v": true, ".mov": true, ".wmv": true,
+	".ts": true, ".m2ts": true, ".webm": true, ".mpg": true, ".mpeg": true,
+	".srt": true, ".ass": true, ".ssa": true, ".sub": true, ".idx": true, ".nfo": true,
+}
+
+// Parse extracts metadata from a scene or P2P style release name
+func Parse(name string) Info {
+	name = filepath.Base(name)
+	if mediaExtensions[strings.ToLower(filepath.Ext(name))] {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	var info Info
+
+	// The group is the suffix after the last dash, before separators are normalized
+	if match := groupPattern.FindStringSubmatch(name); match != nil {
+		info.Group = match[1]
+	}
+
+	normalized := strings.NewReplacer(".", " ", "_", " ").Replace(name)
+
+	// titleEnd tracks where the first metadata token starts; the title precedes it
+	titleEnd := len(normalized)
+	mark := func(index int) {
+		if index >= 0 && index < titleEnd {
+			titleEnd = index
+		}
+	}
+
+	if loc := episodePattern.FindStringSubmatchIndex(normalized); loc != nil {
+		info.Season = atoi(normalized[loc[2]:loc[3]])
+		info.Episode = atoi(normalized[loc[4]:loc[5]])
+		info.IsEpisode = true
+		mark(loc[0])
+	} else if loc := crossEpPattern.FindStringSubmatchIndex(normalized); loc != nil {
+		info.Season = atoi(normalized[loc[2]:loc[3]])
+		info.Episode = atoi(normalized[loc[4]:loc[5]])
+		info.IsEpisode = true
+		mark(loc[0])
+	} else if loc := seasonPattern.FindStringSubmatchIndex(normalized); loc != nil {
+		if loc[2] != -1 {
+			info.Season = atoi(normalized[loc[2]:loc[3]])
+		} else {
+			info.Season = atoi(normalized[loc[4]:loc[5]])
+		}
+		info.IsEpisode = true
+		mark(loc[0])
+	}
+
+	if loc := resolutionPattern.FindStringSubmatchIndex(normalized); loc != nil {
+		info.Resolution = normalizeResolution(normalized[loc[2]:loc[3]])
+		mark(loc[0])
+	}
+
+	// Take the last year before the episode or resolution marker, so titles that
+	// contain a year (e.g. "Blade Runner 2049 2017") keep it. A year at the very
+	// start is always part of the title.
+	yearStart := -1
+	for _, loc := range yearPattern.FindAllStringSubmatchIndex(normalized, -1) {
+		if loc[0] == 0 {
+			continue
+		}
+		if yearStart != -1 && loc[0] > titleEnd {
+			break
+		}
+		yearStart = loc[0]
+		info.Year = atoi(normalized[loc[2]:loc[3]])
+	}
+	mark(yearStart)
+
+	// Source and codec words are common in titles, so only trust them after
+	// the stronger markers when those exist
+	strongEnd := titleEnd
+	if strongEnd == len(normalized) {
+		strongEnd = 0
+	}
+	if loc := findAfter(sourcePattern, normalized, strongEnd); loc != nil {
+		info.Source = normalizeSource(normalized[loc[2]:loc[3]])
+		mark(loc[0])
+		// Remuxes also mention their disc source; the remux tag is what matters
+		if remuxPattern.MatchString(normalized[loc[0]:]) {
+			info.Source = "Remux"
+		}
+	}
+	if loc := findAfter(codecPattern, normalized, strongEnd); loc != nil {
+		info.Codec = normalizeCodec(normalized[loc[2]:loc[3]])
+		mark(loc[0])
+	}
+
+	title := bracketPattern.ReplaceAllString(normalized[:titleEnd], " ")
+	if info.Group != "" && titleEnd == len(normalized) {
+		title = strings.TrimSuffix(strings.TrimSpace(title), "-"+info.Group)
+	}
+	title = strings.Trim(spacePattern.ReplaceAllString(title, " "), " -([{")
+	info.Title = title
+
+	return info
+}
+
+// findAfter returns the submatch indexes of the first match starting at or after offset
+func findAfter(pattern *regexp.Regexp, s string, offset int) []int {
+	for _, loc := range pattern.FindAllStringSubmatchIndex(s, -1) {
+		if loc[0] >= offset {
+			return loc
+		}
+	}
+	return nil
+}
+
+// normalizeResolution maps resolution tokens to a canonical form
+func normalizeResolution(token string) string {
+	token = strings.ToLower(token)
+	switch token {
+	case "4k", "uhd":
+		return "2160p"
+	}
+	return strings.Replace(token, "i", "p", 1)
+}
+
+// normalizeSource maps source tokens to a canonical form
+func normalizeSource(token string) string {
+	token = strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(token))
+	switch token {
+	case "remux":
+		return "Remux"
+	case "bluray", "bdrip", "brrip":
+		return "BluRay"
+	case "webdl", "web":
+		return "WEB-DL"
+	case "webrip":
+		return "WEBRip"
+	case "hdtv":
+		return "HDTV"
+	case "dvdrip", "dvd":
+		return "DVD"
+	case "hdrip":
+		return "HDRip"
+	case "cam", "telesync":
+		return "CAM"
+	}
+	return token
+}
+
+// normalizeCodec maps codec tokens to a canonical form
+func normalizeCodec(token string) string {
+	token = strings.ToLower(strings.NewReplacer(" ", "", ".", "").Replace(token))
+	switch token {
+	case "x265", "h265", "hevc":
+		return "x265"
+	case "x264", "h264", "avc":
+		return "x264"
+	case "av1":
+		return "AV1"
+	case "xvid", "divx":
+		return "XviD"
+	case "vp9":
+		return "VP9"
+	}
+	return token
+}
+
+// atoi converts a matched number, returning 0 on failure
+func atoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,67 @@
+package release
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		want Info
+	}{
+		{
+			name: "The.Matrix.1999.1080p.BluRay.x264-GROUP.mkv",
+			want: Info{Title: "The Matrix", Year: 1999, Resolution: "1080p", Source: "BluRay", Codec: "x264", Group: "GROUP"},
+		},
+		{
+			name: "Blade.Runner.2049.2017.2160p.UHD.BluRay.REMUX.HEVC-FGT",
+			want: Info{Title: "Blade Runner 2049", Year: 2017, Resolution: "2160p", Source: "Remux", Codec: "x265", Group: "FGT"},
+		},
+		{
+			name: "2001.A.Space.Odyssey.1968.720p.BRRip.XviD-AbC",
+			want: Info{Title: "2001 A Space Odyssey", Year: 1968, Resolution: "720p", Source: "BluRay", Codec: "XviD", Group: "AbC"},
+		},
+		{
+			name: "Dune Part Two (2024) [2160p] [4K] [WEB] [x265]",
+			want: Info{Title: "Dune Part Two", Year: 2024, Resolution: "2160p", Source: "WEB-DL", Codec: "x265"},
+		},
+		{
+			name: "Some_Movie_2010_DVDRip_XviD",
+			want: Info{Title: "Some Movie", Year: 2010, Source: "DVD", Codec: "XviD"},
+		},
+		{
+			name: "Breaking.Bad.S05E14.720p.HDTV.x264-IMMERSE.mkv",
+			want: Info{Title: "Breaking Bad", Season: 5, Episode: 14, Resolution: "720p", Source: "HDTV", Codec: "x264", Group: "IMMERSE", IsEpisode: true},
+		},
+		{
+			name: "The Office US S02E01E02 1080p WEB-DL H.264-NTb",
+			want: Info{Title: "The Office US", Season: 2, Episode: 1, Resolution: "1080p", Source: "WEB-DL", Codec: "x264", Group: "NTb", IsEpisode: true},
+		},
+		{
+			name: "Show.Name.3x07.WEBRip.x265-RARBG",
+			want: Info{Title: "Show Name", Season: 3, Episode: 7, Source: "WEBRip", Codec: "x265", Group: "RARBG", IsEpisode: true},
+		},
+		{
+			name: "Dark.Season.2.1080p.NF.WEB-DL.DDP5.1.x264-MZABI",
+			want: Info{Title: "Dark", Season: 2, Resolution: "1080p", Source: "WEB-DL", Codec: "x264", Group: "MZABI", IsEpisode: true},
+		},
+		{
+			name: "Web.Therapy.S01E01.HDTV.XviD-LOL",
+			want: Info{Title: "Web Therapy", Season: 1, Episode: 1, Source: "HDTV", Codec: "XviD", Group: "LOL", IsEpisode: true},
+		},
+		{
+			name: "[SubGroup] Anime Title - S01E05 [1080p].mkv",
+			want: Info{Title: "Anime Title", Season: 1, Episode: 5, Resolution: "1080p", IsEpisode: true},
+		},
+		{
+			name: "Plain Title",
+			want: Info{Title: "Plain Title"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.name); got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package release
+
+import "strings"
+
+// QualityProfile ranks releases by resolution first and source second.
+// Values are listed from best to worst; values not in the list rank below all listed ones.
+type QualityProfile struct {
+	Resolutions []string
+	Sources     []string
+}
+
+// DefaultQualityProfile is used when no quality profile is configured
+var DefaultQualityProfile = QualityProfile{
+	Resolutions: []string{"2160p", "1080p", "720p", "576p", "480p"},
+	Sources:     []string{"Remux", "BluRay", "WEB-DL", "WEBRip", "HDTV", "HDRip", "DVD", "CAM"},
+}
+
+// Known reports whether the profile can rank a release at all
+func (p QualityProfile) Known(info Info) bool {
+	return rank(p.Resolutions, info.Resolution) >= 0 || rank(p.Sources, info.Source) >= 0
+}
+
+// Compare returns 1 if a ranks higher than b, -1 if lower and 0 if equal
+func (p QualityProfile) Compare(a, b Info) int {
+	if c := compareRank(rank(p.Resolutions, a.Resolution), rank(p.Resolutions, b.Resolution)); c != 0 {
+		return c
+	}
+	return compareRank(rank(p.Sources, a.Source), rank(p.Sources, b.Source))
+}
+
+// rank returns the position of a value in a best-first list, higher is better, -1 if unlisted
+func rank(list []string, value string) int {
+	for i, candidate := range list {
+		if strings.EqualFold(candidate, value) {
+			return len(list) - i
+		}
+	}
+	return -1
+}
+
+// compareRank compares two ranks returned by rank
+func compareRank(a, b int) int {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,61 @@
+package release
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// NameData is the data available to naming templates
+type NameData struct {
+	Info
+	Ext      string // File extension including the dot, e.g. ".mkv"
+	Original string // Original file name without extension
+	Torrent  string // Torrent name
+}
+
+// templateFuncs are the helper functions available to naming templates
+var templateFuncs = template.FuncMap{
+	// pad formats a number with at least two digits, e.g. {{pad .Season}} -> "01"
+	"pad": func(n int) string {
+		return fmt.Sprintf("%02d", n)
+	},
+}
+
+// unsafeChars are replaced in template values so they cannot create extra
+// path elements or names that are invalid on common filesystems
+var unsafeChars = strings.NewReplacer("/", "-", "\\", "-", ":", " -", "*", "", "?", "", "\"", "", "<", "", ">", "", "|", "")
+
+// ParseTemplate parses a naming template such as
+// "{{.Title}} ({{.Year}})/{{.Title}} ({{.Year}}) - {{.Resolution}}{{.Ext}}"
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("name").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// Render executes a naming template and returns a clean relative path
+func Render(tmpl *template.Template, data NameData) (string, error) {
+	data.Title = sanitize(data.Title)
+	data.Resolution = sanitize(data.Resolution)
+	data.Source = sanitize(data.Source)
+	data.Codec = sanitize(data.Codec)
+	data.Group = sanitize(data.Group)
+	data.Original = sanitize(data.Original)
+	data.Torrent = sanitize(data.Torrent)
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render naming template: %w", err)
+	}
+
+	rendered := filepath.Clean(strings.TrimSpace(out.String()))
+	if rendered == "." || filepath.IsAbs(rendered) || rendered == ".." || strings.HasPrefix(rendered, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("naming template produced invalid relative path %q", out.String())
+	}
+	return rendered, nil
+}
+
+// sanitize makes a template value safe to use as part of a file name
+func sanitize(value string) string {
+	return strings.TrimSpace(unsafeChars.Replace(value))
+}
@@ -0,0 +1,34 @@
+package state
+
+import "time"
+
+// brokenFile keeps the recovery state of broken torrents between runs
+const brokenFile = "broken.json"
+
+// BrokenTorrent is the recovery state of a torrent seen in an error or missingFiles state
+type BrokenTorrent struct {
+	FirstSeen   time.Time `json:"first_seen"`   // when the torrent was first seen broken
+	LastAttempt time.Time `json:"last_attempt"` // when the last recovery cycle started
+	Attempts    int       `json:"attempts"`     // recovery cycles started
+	Broken      bool      `json:"broken"`       // whether the torrent was broken when last seen
+	Quarantined bool      `json:"quarantined"`
+}
+
+// BrokenTorrents returns the recovery state of broken torrents by hash
+func (s *Store) BrokenTorrents() (map[string]*BrokenTorrent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	broken := make(map[string]*BrokenTorrent)
+	if err := s.load(brokenFile, &broken); err != nil {
+		return nil, err
+	}
+	return broken, nil
+}
+
+// SaveBrokenTorrents replaces the recovery state of broken torrents
+func (s *Store) SaveBrokenTorrents(broken map[string]*BrokenTorrent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.save(brokenFile, broken)
+}
@@ -0,0 +1,54 @@
+package state
+
+import "strings"
+
+// importsFile records which torrent each imported destination came from
+const importsFile = "imports.json"
+
+// Imports returns the recorded destinations and the hashes of the torrents they were imported from
+func (s *Store) Imports() (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	imports := make(map[string]string)
+	if err := s.load(importsFile, &imports); err != nil {
+		return nil, err
+	}
+	return imports, nil
+}
+
+// AddImports records the destinations a torrent was imported to
+func (s *Store) AddImports(hash string, destinations []string) error {
+	if len(destinations) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	imports := make(map[string]string)
+	if err := s.load(importsFile, &imports); err != nil {
+		return err
+	}
+	for _, destination := range destinations {
+		imports[destination] = strings.ToLower(hash)
+	}
+	return s.save(importsFile, imports)
+}
+
+// ForgetImports drops destinations from the record
+func (s *Store) ForgetImports(destinations []string) error {
+	if len(destinations) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	imports := make(map[string]string)
+	if err := s.load(importsFile, &imports); err != nil {
+		return err
+	}
+	for _, destination := range destinations {
+		delete(imports, destination)
+	}
+	return s.save(importsFile, imports)
+}
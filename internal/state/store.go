@@ -0,0 +1,72 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/perms"
+)
+
+// Store keeps JSON state files that outlive a run in the state directory
+type Store struct {
+	cfg     *config.MonitorConfig
+	mu      sync.Mutex
+	written map[string][]byte // last content of each file, to skip rewriting unchanged state
+}
+
+// New creates a state store from configuration
+func New(cfg *config.MonitorConfig) *Store {
+	return &Store{cfg: cfg, written: make(map[string][]byte)}
+}
+
+// load decodes a state file into v, leaving v unchanged if the file does not exist
+func (s *Store) load(name string, v interface{}) error {
+	path := filepath.Join(s.cfg.StateDir, name)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		// Unchanged empty state is not written, so a missing file stays missing
+		s.written[name], _ = json.MarshalIndent(v, "", "  ")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse state %s: %w", path, err)
+	}
+	s.written[name] = data
+	return nil
+}
+
+// save replaces a state file atomically
+func (s *Store) save(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	if bytes.Equal(data, s.written[name]) {
+		return nil
+	}
+	if err := perms.MkdirAll(s.cfg, s.cfg.StateDir); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	path := filepath.Join(s.cfg.StateDir, name)
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := perms.ApplyFile(s.cfg, tmpPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	s.written[name] = data
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"qb-sync/internal/qbit"
 )
 
 // Bot represents the Telegram bot client
@@ -19,16 +21,8 @@ type Bot struct {
 
 // QBClient interface for qBittorrent operations
 type QBClient interface {
-	GetAllTorrents(ctx context.Context) ([]TorrentInfo, error)
-	AddTorrent(ctx context.Context, magnetLink, category string) error
-}
-
-// TorrentInfo represents basic torrent information for /status command
-type TorrentInfo struct {
-	Name     string
-	Category string
-	State    string
-	Hash     string
+	ListAllTorrents(ctx context.Context) ([]qbit.Torrent, error)
+	AddTorrentFromMagnet(ctx context.Context, magnetLink, category string) error
 }
 
 // NewBot creates a new Telegram bot instance
@@ -4,14 +4,18 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"qb-sync/internal/files"
+	"qb-sync/internal/qbit"
 )
 
 // handleStatusCommand handles the /status command
 func (b *Bot) handleStatusCommand(ctx context.Context, message *tgbotapi.Message) {
-	torrents, err := b.qbClient.GetAllTorrents(ctx)
+	torrents, err := b.qbClient.ListAllTorrents(ctx)
 	if err != nil {
 		log.Printf("telegram: failed to get torrents for /status: %v", err)
 		b.sendMessage(message.Chat.ID, "❌ *Error*\n\nFailed to retrieve torrent status. Please try again later.")
@@ -28,30 +32,26 @@ func (b *Bot) handleStatusCommand(ctx context.Context, message *tgbotapi.Message
 	statusText.WriteString("📊 *Torrent Status*\n\n")
 
 	// Group torrents by state for better organization
-	torrentsByState := make(map[string][]TorrentInfo)
+	torrentsByState := make(map[qbit.State][]qbit.Torrent)
 	for _, torrent := range torrents {
 		torrentsByState[torrent.State] = append(torrentsByState[torrent.State], torrent)
 	}
 
-	// Define state order and icons
-	stateOrder := []string{"downloading", "stalledDL", "stalledUP", "uploading", "completed", "pausedUP", "pausedDL", "error", "missingFiles"}
-	stateIcons := map[string]string{
-		"downloading": "⬇️",
-		"stalledDL":   "⏸️",
-		"stalledUP":   "⏸️",
-		"uploading":   "⬆️",
-		"completed":   "✅",
-		"pausedUP":    "⏸️",
-		"pausedDL":    "⏸️",
-		"error":       "❌",
-		"missingFiles": "❌",
+	// Known states first, followed by anything the server reported that we don't know about
+	var unknownStates []qbit.State
+	for state := range torrentsByState {
+		if !state.IsKnown() {
+			unknownStates = append(unknownStates, state)
+		}
 	}
+	sort.Slice(unknownStates, func(i, j int) bool { return unknownStates[i] < unknownStates[j] })
+	stateOrder := append(append([]qbit.State{}, qbit.AllStates...), unknownStates...)
 
 	// Display torrents by state
 	for _, state := range stateOrder {
 		if torrents, exists := torrentsByState[state]; exists && len(torrents) > 0 {
-			icon := stateIcons[state]
-			stateName := b.formatStateName(state)
+			icon := stateIcon(state)
+			stateName := state.DisplayName()
 			statusText.WriteString(fmt.Sprintf("%s *%s* (%d)\n", icon, stateName, len(torrents)))
 
 			for i, torrent := range torrents {
@@ -110,7 +110,7 @@ func (b *Bot) handleAddCommand(ctx context.Context, message *tgbotapi.Message) {
 	log.Printf("telegram: adding torrent %s for user %d", logPrefix, message.From.ID)
 
 	// Add torrent using qBittorrent client
-	err := b.qbClient.AddTorrent(ctx, magnetLink, "")
+	err := b.qbClient.AddTorrentFromMagnet(ctx, magnetLink, "")
 	if err != nil {
 		log.Printf("telegram: failed to add torrent %s: %v", logPrefix, err)
 		b.sendMessage(message.Chat.ID, "❌ *Error*\n\nFailed to add torrent. Please check the magnet link and try again.")
@@ -128,34 +128,24 @@ func (b *Bot) handleAddCommand(ctx context.Context, message *tgbotapi.Message) {
 	b.sendMessage(message.Chat.ID, successText)
 }
 
-// formatStateName converts qBittorrent state names to user-friendly names
-func (b *Bot) formatStateName(state string) string {
-	stateNames := map[string]string{
-		"downloading":  "Downloading",
-		"stalledDL":    "Stalled (Downloading)",
-		"stalledUP":    "Stalled (Uploading)",
-		"uploading":    "Uploading",
-		"completed":    "Completed",
-		"pausedUP":     "Paused (Uploading)",
-		"pausedDL":     "Paused (Downloading)",
-		"error":        "Error",
-		"missingFiles": "Missing Files",
-		"checkingDL":   "Checking (Downloading)",
-		"checkingUP":   "Checking (Uploading)",
-		"checkingResumeData": "Checking Resume Data",
-		"moving":       "Moving",
-		"queuedDL":     "Queued (Downloading)",
-		"queuedUP":     "Queued (Uploading)",
-		"forcedDL":     "Forced (Downloading)",
-		"forcedUP":     "Forced (Uploading)",
-		"allocating":   "Allocating",
-		"metaDL":       "Downloading Metadata",
-	}
-
-	if name, exists := stateNames[state]; exists {
-		return name
-	}
-	return state
+// stateIcon returns the icon used for a state in status messages
+func stateIcon(state qbit.State) string {
+	switch {
+	case state.IsError():
+		return "❌"
+	case state.IsTransitional():
+		return "🔄"
+	case state.IsPaused(), state == qbit.StateStalledDL, state == qbit.StateStalledUP:
+		return "⏸️"
+	case state.IsDownloading():
+		return "⬇️"
+	case state.IsSeeding():
+		return "⬆️"
+	case state.IsComplete():
+		return "✅"
+	default:
+		return "❔"
+	}
 }
 
 // isValidMagnetLink checks if the provided string is a valid magnet link
@@ -189,7 +179,74 @@ func (b *Bot) SendTorrentAddedNotification(torrentName string) {
 
 	log.Printf("telegram: sending Plex addition notification for torrent: %s", torrentName)
 
-	// Send notification to all allowed users
+	b.broadcast(message)
+}
+
+// SendBrokenTorrentAlert sends an alert when a torrent stayed broken and was quarantined
+func (b *Bot) SendBrokenTorrentAlert(torrentName string, state qbit.State, tag string) {
+	if !b.isEnabled {
+		return
+	}
+
+	message := fmt.Sprintf("🚨 *Torrent Quarantined*\n\n*%s*\n\nThe torrent is stuck in state *%s* and has been tagged `%s`. It will not be imported until the tag is removed.",
+		torrentName, state.DisplayName(), tag)
+
+	log.Printf("telegram: sending broken torrent alert for torrent: %s", torrentName)
+
+	b.broadcast(message)
+}
+
+// SendOrphanReport sends a summary of orphans found in the destination
+func (b *Bot) SendOrphanReport(dangling, unlinked, emptyDirs int, outcome string) {
+	if !b.isEnabled {
+		return
+	}
+
+	message := fmt.Sprintf("🧹 *Orphans in Destination*\n\nDangling symlinks: %d\nFiles without torrent data: %d\nEmpty directories: %d\n\n%s",
+		dangling, unlinked, emptyDirs, outcome)
+
+	log.Printf("telegram: sending orphan report")
+
+	b.broadcast(message)
+}
+
+// SendDiskSpaceAlert sends an alert when a torrent is deferred for lack of disk space
+func (b *Bot) SendDiskSpaceAlert(torrentName, path string, needed, available int64) {
+	if !b.isEnabled {
+		return
+	}
+
+	message := fmt.Sprintf("💾 *Not Enough Disk Space*\n\n*%s*\n\nThe import needs %s on `%s`, but only %s is available. The torrent will be retried on the next poll.",
+		torrentName, files.FormatSize(needed), path, files.FormatSize(available))
+
+	log.Printf("telegram: sending disk space alert for torrent: %s", torrentName)
+
+	b.broadcast(message)
+}
+
+// SendTestMessage sends a test message to all allowed users and reports those it could not reach
+func (b *Bot) SendTestMessage() error {
+	if !b.isEnabled {
+		return fmt.Errorf("telegram bot is not enabled")
+	}
+
+	var failed []string
+	for userID := range b.allowedUsers {
+		msg := tgbotapi.NewMessage(userID, "✅ *qb-sync Test*\n\nNotifications from qb-sync reach this chat.")
+		msg.ParseMode = "Markdown"
+		if _, err := b.api.Send(msg); err != nil {
+			failed = append(failed, fmt.Sprintf("%d (%v)", userID, err))
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("failed to send to %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// broadcast sends a message to all allowed users
+func (b *Bot) broadcast(message string) {
 	for userID := range b.allowedUsers {
 		b.sendMessage(userID, message)
 	}
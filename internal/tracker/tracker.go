@@ -0,0 +1,235 @@
+package tracker
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"qb-sync/internal/files"
+	"qb-sync/internal/qbit"
+)
+
+// State is the processing state of a tracked torrent
+type State string
+
+const (
+	StatePending     State = "pending"     // seen, not processed yet
+	StateProcessing  State = "processing"  // being processed right now
+	StateProcessed   State = "processed"   // all files imported
+	StateFailed      State = "failed"      // the last attempt failed, retried on the next sync
+	StateDeferred    State = "deferred"    // waiting for free disk space
+	StateSkipped     State = "skipped"     // skipped on request until reprocessed
+	StateQuarantined State = "quarantined" // tagged with the quarantine tag
+)
+
+// FileResult is the outcome of one file operation
+type FileResult struct {
+	Source      string `json:"source"`
+	Entry       string `json:"entry,omitempty"`
+	Destination string `json:"destination"`
+	Size        int64  `json:"size"`
+	Method      string `json:"method"`
+	Decision    string `json:"decision,omitempty"`
+	Success     bool   `json:"success"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Torrent is the tracked state of a torrent
+type Torrent struct {
+	Hash        string       `json:"hash"`
+	Name        string       `json:"name"`
+	Category    string       `json:"category"`
+	State       State        `json:"state"`
+	Message     string       `json:"message,omitempty"` // last error or reason for the state
+	Attempts    int          `json:"attempts"`
+	FirstSeen   time.Time    `json:"first_seen"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	ProcessedAt *time.Time   `json:"processed_at,omitempty"`
+	Files       []FileResult `json:"files,omitempty"`
+}
+
+// Tracker keeps the processing state of torrents in memory
+type Tracker struct {
+	mu       sync.RWMutex
+	torrents map[string]*Torrent
+}
+
+// New creates an empty tracker
+func New() *Tracker {
+	return &Tracker{torrents: make(map[string]*Torrent)}
+}
+
+// Seen records a torrent found in qBittorrent
+func (t *Tracker) Seen(torrent *qbit.Torrent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry := t.entry(torrent.Hash)
+	entry.Name = torrent.Name
+	entry.Category = torrent.Category
+}
+
+// Start marks a torrent as being processed
+func (t *Tracker) Start(hash string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry := t.entry(hash)
+	entry.Attempts++
+	t.set(entry, StateProcessing, "")
+}
+
+// Finish records the outcome of processing a torrent. A state set while
+// processing, such as deferred, is kept when the torrent did not fail.
+func (t *Tracker) Finish(hash string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry := t.entry(hash)
+
+	failed, kept := 0, 0
+	for _, file := range entry.Files {
+		switch {
+		case !file.Success:
+			failed++
+		case file.Decision == files.DecisionSkipped:
+			kept++
+		}
+	}
+	switch {
+	case err != nil:
+		t.set(entry, StateFailed, err.Error())
+	case failed > 0:
+		t.set(entry, StateFailed, pluralize(failed, "file operation")+" failed")
+	case entry.State == StateProcessing:
+		message := ""
+		if kept > 0 {
+			message = pluralize(kept, "file") + " not imported, existing copies kept"
+		}
+		t.set(entry, StateProcessed, message)
+		now := entry.UpdatedAt
+		entry.ProcessedAt = &now
+	}
+}
+
+// SetFiles records the file operations of the current attempt
+func (t *Tracker) SetFiles(hash string, operations []*files.FileOperation) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry := t.entry(hash)
+	entry.Files = make([]FileResult, 0, len(operations))
+	for _, op := range operations {
+		result := FileResult{
+			Source:      op.Source,
+			Entry:       op.Entry,
+			Destination: op.Destination,
+			Size:        op.Size,
+			Method:      op.Method,
+			Decision:    op.Decision,
+			Success:     op.Success,
+		}
+		if op.Error != nil {
+			result.Error = op.Error.Error()
+		}
+		entry.Files = append(entry.Files, result)
+	}
+}
+
+// SetState sets the state of a torrent with a reason
+func (t *Tracker) SetState(hash string, state State, message string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.set(t.entry(hash), state, message)
+}
+
+// Skip marks a torrent to be left alone until it is reprocessed
+func (t *Tracker) Skip(hash string) {
+	t.SetState(hash, StateSkipped, "skipped on request")
+}
+
+// Unskip makes a skipped torrent eligible for processing again
+func (t *Tracker) Unskip(hash string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if entry, ok := t.torrents[strings.ToLower(hash)]; ok && entry.State == StateSkipped {
+		t.set(entry, StatePending, "")
+	}
+}
+
+// Skipped reports whether a torrent was skipped on request
+func (t *Tracker) Skipped(hash string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	entry, ok := t.torrents[strings.ToLower(hash)]
+	return ok && entry.State == StateSkipped
+}
+
+// Get returns a copy of a tracked torrent
+func (t *Tracker) Get(hash string) (Torrent, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	entry, ok := t.torrents[strings.ToLower(hash)]
+	if !ok {
+		return Torrent{}, false
+	}
+	return entry.clone(), true
+}
+
+// List returns copies of all tracked torrents ordered by name
+func (t *Tracker) List() []Torrent {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	list := make([]Torrent, 0, len(t.torrents))
+	for _, entry := range t.torrents {
+		list = append(list, entry.clone())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+	})
+	return list
+}
+
+// Prune forgets torrents that are no longer in qBittorrent and were not updated since cutoff.
+// Skipped torrents are kept so a skip survives a torrent being re-added.
+func (t *Tracker) Prune(present map[string]bool, cutoff time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for hash, entry := range t.torrents {
+		if !present[hash] && entry.State != StateSkipped && entry.UpdatedAt.Before(cutoff) {
+			delete(t.torrents, hash)
+		}
+	}
+}
+
+// entry returns the tracked torrent for a hash, creating it if needed
+func (t *Tracker) entry(hash string) *Torrent {
+	hash = strings.ToLower(hash)
+	entry, ok := t.torrents[hash]
+	if !ok {
+		now := time.Now()
+		entry = &Torrent{Hash: hash, State: StatePending, FirstSeen: now, UpdatedAt: now}
+		t.torrents[hash] = entry
+	}
+	return entry
+}
+
+// set changes the state of a tracked torrent
+func (t *Tracker) set(entry *Torrent, state State, message string) {
+	entry.State = state
+	entry.Message = message
+	entry.UpdatedAt = time.Now()
+}
+
+// clone returns a copy that does not share the file results
+func (e *Torrent) clone() Torrent {
+	c := *e
+	c.Files = append([]FileResult(nil), e.Files...)
+	return c
+}
+
+// pluralize formats a count with a singular or plural noun
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return strconv.Itoa(n) + " " + noun + "s"
+}
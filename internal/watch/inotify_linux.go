@@ -0,0 +1,176 @@
+//go:build linux
+
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// watchMask selects the inotify events a watcher listens to
+const watchMask = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_CREATE | syscall.IN_DELETE_SELF
+
+// rootGone are the events telling that the watched root itself went away
+const rootGone = syscall.IN_DELETE_SELF | syscall.IN_IGNORED | syscall.IN_UNMOUNT
+
+// New starts watching a directory tree with inotify. Directories created later are watched too.
+func New(root string) (*Watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+	// A non-blocking descriptor uses the runtime poller, so Close unblocks a pending Read
+	file := os.NewFile(uintptr(fd), "inotify")
+
+	w := &Watcher{
+		root:   root,
+		events: make(chan string, 64),
+		errors: make(chan error, 8),
+		close:  file.Close,
+	}
+	watcher := &inotify{fd: fd, file: file, dirs: make(map[int32]string), w: w}
+	if err := watcher.addTree(root); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	go watcher.read()
+	return w, nil
+}
+
+// inotify reads events from an inotify descriptor
+type inotify struct {
+	fd     int
+	file   *os.File
+	mu     sync.Mutex
+	dirs   map[int32]string // watch descriptor -> directory
+	rootWd int32
+	w      *Watcher
+}
+
+// addTree watches a directory and all directories below it
+func (n *inotify) addTree(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return fmt.Errorf("failed to watch %s: %w", root, err)
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		wd, err := syscall.InotifyAddWatch(n.fd, path, watchMask)
+		if err != nil {
+			if path == root {
+				return fmt.Errorf("failed to watch %s: %w", root, err)
+			}
+			n.report(fmt.Errorf("failed to watch %s: %w", path, err))
+			return nil
+		}
+		n.mu.Lock()
+		n.dirs[int32(wd)] = path
+		if path == root && root == n.w.root {
+			n.rootWd = int32(wd)
+		}
+		n.mu.Unlock()
+		return nil
+	})
+}
+
+// read decodes inotify events until the descriptor is closed
+func (n *inotify) read() {
+	defer close(n.w.events)
+	defer close(n.w.errors)
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		count, err := n.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				n.report(fmt.Errorf("failed to read inotify events: %w", err))
+			}
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= count; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+			offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+			name := string(nameBytes)
+			for len(name) > 0 && name[len(name)-1] == 0 {
+				name = name[:len(name)-1]
+			}
+			n.handle(event, name)
+		}
+	}
+}
+
+// handle forwards relevant events and watches new directories
+func (n *inotify) handle(event *syscall.InotifyEvent, name string) {
+	if event.Mask&syscall.IN_Q_OVERFLOW != 0 {
+		// Events were lost, a sync catches up with whatever happened
+		n.emit(n.w.root)
+		return
+	}
+
+	if event.Wd == n.rootWd && event.Mask&rootGone != 0 {
+		// Nothing below the root can be watched anymore, stop so the owner can restart
+		n.report(fmt.Errorf("watched directory %s was removed or unmounted", n.w.root))
+		n.file.Close()
+		return
+	}
+
+	n.mu.Lock()
+	dir, ok := n.dirs[event.Wd]
+	if event.Mask&syscall.IN_IGNORED != 0 {
+		delete(n.dirs, event.Wd)
+	}
+	n.mu.Unlock()
+	if !ok || name == "" {
+		return
+	}
+	path := filepath.Join(dir, name)
+
+	switch {
+	case event.Mask&syscall.IN_ISDIR != 0:
+		if event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+			n.addTree(path)
+			// A directory moved into place may already hold complete files
+			if event.Mask&syscall.IN_MOVED_TO != 0 {
+				n.emit(path)
+			}
+		}
+	case incomplete(name):
+	case event.Mask&(syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO) != 0:
+		n.emit(path)
+	case event.Mask&syscall.IN_CREATE != 0:
+		// Emulators such as decypharr create symlinks instead of writing files
+		if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			n.emit(path)
+		}
+	}
+}
+
+// emit forwards an event without blocking the reader
+func (n *inotify) emit(path string) {
+	select {
+	case n.w.events <- path:
+	default:
+	}
+}
+
+// report forwards an error without blocking the reader
+func (n *inotify) report(err error) {
+	select {
+	case n.w.errors <- err:
+	default:
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package watch
+
+// New starts watching a directory tree; filesystem events are only supported on Linux
+func New(root string) (*Watcher, error) {
+	return nil, ErrUnsupported
+}
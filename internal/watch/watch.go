@@ -0,0 +1,82 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrUnsupported is returned when filesystem events are not available on the platform
+var ErrUnsupported = errors.New("filesystem watching is not supported on this platform")
+
+// Watcher reports filesystem activity under a directory tree that may complete a torrent:
+// files closed after writing, files renamed into place and symlinks created by emulators
+type Watcher struct {
+	root   string
+	events chan string
+	errors chan error
+	close  func() error
+}
+
+// Events returns the paths of relevant filesystem events
+func (w *Watcher) Events() <-chan string {
+	return w.events
+}
+
+// Errors returns errors that occurred while watching
+func (w *Watcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	return w.close()
+}
+
+// Debounce calls trigger once no event arrived for the debounce period, so a torrent
+// finishing many files at once causes a single sync. It returns nil when ctx is done,
+// or an error if the watcher stopped, e.g. because the watched directory was removed.
+func (w *Watcher) Debounce(ctx context.Context, debounce time.Duration, trigger func(), onError func(error)) error {
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	errs := w.errors
+	var lastErr error
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-w.events:
+			if !ok {
+				// The reader closes errors before events, so this does not block
+				if errs != nil {
+					for err := range errs {
+						lastErr = err
+					}
+				}
+				if lastErr != nil {
+					return fmt.Errorf("watcher stopped: %w", lastErr)
+				}
+				return errors.New("watcher stopped")
+			}
+			timer.Reset(debounce)
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			lastErr = err
+			onError(err)
+		case <-timer.C:
+			trigger()
+		}
+	}
+}
+
+// incomplete reports whether a name is a partial qBittorrent download
+func incomplete(name string) bool {
+	return strings.HasSuffix(name, ".!qB")
+}
@@ -0,0 +1,324 @@
+package worker
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"qb-sync/internal/files"
+	"qb-sync/internal/plan"
+	"qb-sync/internal/qbit"
+)
+
+// Outcomes of plan steps reported by ApplyPlan
+const (
+	ApplyApplied = "applied"
+	ApplyValid   = "valid"   // matches the current state, nothing was executed (dry run)
+	ApplyDrift   = "drift"   // no longer matches the current state and was not executed
+	ApplyFailed  = "failed"  // was executed and failed, or was planned with an error
+	ApplySkipped = "skipped" // not run because another step of the torrent did not apply
+)
+
+// Kind of the step result for a Plex refresh
+const applyPlexRefresh = "plex-refresh"
+
+// StepResult is the outcome of one step of an applied plan. Kind is the method
+// of a file operation, plex-refresh or an action kind.
+type StepResult struct {
+	Hash        string `json:"hash"`
+	Torrent     string `json:"torrent"`
+	Kind        string `json:"kind"`
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	Outcome     string `json:"outcome"`
+	Detail      string `json:"detail,omitempty"`
+}
+
+// ApplyPlan executes a reviewed plan. Every step is checked against the current
+// state first: the torrent must still be complete in qBittorrent, its sources must
+// be ready and fit on the destination, the source must exist with the planned size
+// and the destination must resolve to the planned decision. Steps that no longer
+// match are reported as drift and not executed.
+// Plex refreshes and torrent actions only run once all steps of a torrent applied.
+// In dry-run mode the steps are only checked.
+func (m *Monitor) ApplyPlan(p *plan.Plan) ([]StepResult, error) {
+	if err := m.checkPlanSettings(p); err != nil {
+		return nil, err
+	}
+	m.detectVersion()
+
+	torrents, err := m.client.ListAllTorrents(m.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list torrents: %w", err)
+	}
+	byHash := make(map[string]*qbit.Torrent, len(torrents))
+	for i := range torrents {
+		byHash[strings.ToLower(torrents[i].Hash)] = &torrents[i]
+	}
+
+	var results []StepResult
+	for i := range p.Torrents {
+		planned := &p.Torrents[i]
+		results = append(results, m.applyTorrent(planned, byHash[strings.ToLower(planned.Hash)])...)
+	}
+	return results, nil
+}
+
+// checkPlanSettings refuses plans made for a different destination, operation or conflict policy
+func (m *Monitor) checkPlanSettings(p *plan.Plan) error {
+	cfg := &m.config.Monitor
+	switch {
+	case p.Destination != cfg.DestPath:
+		return fmt.Errorf("plan was made for destination %s, configured is %s", p.Destination, cfg.DestPath)
+	case p.Operation != cfg.Operation:
+		return fmt.Errorf("plan was made for operation %s, configured is %s", p.Operation, cfg.Operation)
+	case p.Conflict != cfg.ConflictPolicy:
+		return fmt.Errorf("plan was made for conflict policy %s, configured is %s", p.Conflict, cfg.ConflictPolicy)
+	}
+	return nil
+}
+
+// applyTorrent applies the steps, Plex refreshes and actions planned for one torrent
+func (m *Monitor) applyTorrent(planned *plan.Torrent, torrent *qbit.Torrent) []StepResult {
+	var results []StepResult
+	result := func(kind, source, destination, outcome, detail string) {
+		results = append(results, StepResult{
+			Hash:        planned.Hash,
+			Torrent:     planned.Name,
+			Kind:        kind,
+			Source:      source,
+			Destination: destination,
+			Outcome:     outcome,
+			Detail:      detail,
+		})
+	}
+
+	// Drift of the whole torrent applies to each of its steps
+	var drift string
+	var current []files.PlannedOp
+	switch {
+	case torrent == nil:
+		drift = "torrent is no longer in qBittorrent"
+	case len(qbit.FilterCompletedTorrents([]qbit.Torrent{*torrent}, "")) == 0:
+		drift = fmt.Sprintf("torrent is not complete (state: %s)", torrent.State.DisplayName())
+	case torrent.HasTag(m.config.Monitor.QuarantineTag):
+		drift = "torrent is quarantined"
+	default:
+		var err error
+		if current, err = m.planTorrent(torrent); err != nil {
+			drift = err.Error()
+		} else {
+			drift = m.applyPreflight(torrent, current)
+		}
+	}
+
+	remaining := make(map[string]*files.PlannedOp, len(current))
+	for i := range current {
+		remaining[current[i].Method+"\x00"+current[i].Source] = &current[i]
+	}
+
+	complete := true
+	var destPaths []string
+	for _, step := range planned.Steps {
+		op := remaining[step.Method+"\x00"+step.Source]
+		delete(remaining, step.Method+"\x00"+step.Source)
+
+		reason := drift
+		switch {
+		case reason != "":
+		case step.Error != "":
+			result(step.Method, step.Source, step.Destination, ApplyFailed, "planned with error: "+step.Error)
+			complete = false
+			continue
+		case op == nil:
+			reason = "step is no longer planned"
+		default:
+			reason = m.stepDrift(torrent, op, &step)
+		}
+		if reason != "" {
+			m.logger.Printf("Not applying %s of %s: %s", step.Method, step.Source, reason)
+			result(step.Method, step.Source, step.Destination, ApplyDrift, reason)
+			complete = false
+			continue
+		}
+
+		if m.config.Monitor.DryRun {
+			result(step.Method, step.Source, step.Destination, ApplyValid, step.Decision)
+			continue
+		}
+
+		operations, err := files.ExecuteOp(&m.config.Monitor, torrent, op)
+		m.recordImports(torrent.Hash, operations)
+		if err != nil {
+			m.logger.Printf("Failed to %s '%s': %v", op.Method, op.Source, err)
+			result(step.Method, step.Source, step.Destination, ApplyFailed, err.Error())
+			complete = false
+			continue
+		}
+		decisions := make(map[string]int)
+		for _, operation := range operations {
+			if operation.Success && operation.Decision != files.DecisionRecycled && operation.Decision != files.DecisionSkipped {
+				destPaths = append(destPaths, operation.Destination)
+			}
+			decisions[operation.Decision]++
+		}
+		m.logger.Printf("Applied %s of %s to %s", op.Method, op.Source, op.Destination)
+		result(step.Method, step.Source, step.Destination, ApplyApplied, strings.TrimPrefix(formatDecisions(decisions), ", "))
+	}
+
+	// Operations the plan does not know about were never reviewed
+	if drift == "" {
+		for i := range current {
+			op := &current[i]
+			if remaining[op.Method+"\x00"+op.Source] == op {
+				result(op.Method, op.Source, op.Destination, ApplyDrift, "step is not in the plan")
+				complete = false
+			}
+		}
+	}
+
+	for _, dir := range planned.PlexRefresh {
+		switch {
+		case !complete:
+			result(applyPlexRefresh, "", dir, ApplySkipped, "not all steps applied")
+		case m.plexClient == nil:
+			result(applyPlexRefresh, "", dir, ApplyDrift, "Plex integration is not enabled")
+		case m.config.Monitor.DryRun:
+			result(applyPlexRefresh, "", dir, ApplyValid, "")
+		default:
+			if err := m.plexClient.RefreshPath(m.ctx, dir); err != nil {
+				m.logger.Printf("Failed to refresh Plex path '%s': %v", dir, err)
+				result(applyPlexRefresh, "", dir, ApplyFailed, err.Error())
+				continue
+			}
+			result(applyPlexRefresh, "", dir, ApplyApplied, "")
+		}
+	}
+	if complete && !m.config.Monitor.DryRun && len(planned.PlexRefresh) > 0 && len(destPaths) > 0 &&
+		m.telegramBot != nil && m.telegramBot.IsEnabled() {
+		m.telegramBot.SendTorrentAddedNotification(planned.Name)
+	}
+
+	for _, action := range planned.Actions {
+		if action.Kind != plan.ActionDeleteTorrent {
+			continue
+		}
+		detail := fmt.Sprintf("delete files: %t", action.DeleteFiles)
+		switch {
+		case !complete:
+			result(action.Kind, "", "", ApplySkipped, "not all steps applied")
+		case m.config.Monitor.DryRun:
+			result(action.Kind, "", "", ApplyValid, detail)
+		default:
+			if err := m.deleteTorrent(torrent, action.DeleteFiles); err != nil {
+				result(action.Kind, "", "", ApplyFailed, err.Error())
+				continue
+			}
+			result(action.Kind, "", "", ApplyApplied, detail)
+		}
+	}
+	return results
+}
+
+// stepDrift returns why a planned step no longer matches the current state, or "" if it does
+func (m *Monitor) stepDrift(torrent *qbit.Torrent, op *files.PlannedOp, step *plan.Step) string {
+	var size int64
+	for _, source := range opSources(op) {
+		info, err := os.Stat(source.Source)
+		if err != nil {
+			return fmt.Sprintf("source %s is missing", source.Source)
+		}
+		size += info.Size()
+	}
+	if size != step.Size {
+		return fmt.Sprintf("source size changed from %d to %d bytes", step.Size, size)
+	}
+
+	destination, decision, err := files.PreviewConflict(&m.config.Monitor, torrent, op)
+	if err != nil {
+		return err.Error()
+	}
+	// A step applied earlier finds its own file, which is what the plan asked for
+	if destination != step.Destination || (decision != step.Decision && decision != files.DecisionExists) {
+		return fmt.Sprintf("destination changed: planned %s at %s, now %s at %s", displayDecision(step.Decision), step.Destination, displayDecision(decision), destination)
+	}
+	if path := replacesDrift(op, step, decision); path != "" {
+		return fmt.Sprintf("files replaced by the step changed: %s", path)
+	}
+	return ""
+}
+
+// replacesDrift returns a file whose replacement by a step no longer matches the plan, or "".
+// A replaced file that is gone, e.g. recycled by an earlier apply, or that was replaced
+// in place by the step's own file is satisfied. A replacement the plan does not list is not.
+func replacesDrift(op *files.PlannedOp, step *plan.Step, decision string) string {
+	current := make(map[string]bool, len(op.Replaces))
+	for _, path := range op.Replaces {
+		current[path] = true
+	}
+	planned := make(map[string]bool, len(step.Replaces))
+	for _, path := range step.Replaces {
+		planned[path] = true
+		if current[path] {
+			continue
+		}
+		if path == op.Destination && decision == files.DecisionExists {
+			continue
+		}
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			continue
+		}
+		return path
+	}
+	for _, path := range op.Replaces {
+		if !planned[path] {
+			return path
+		}
+	}
+	return ""
+}
+
+// applyPreflight runs the checks ProcessTorrent does before writing: the sources must be
+// visible on the mount and the destination must have room for what the operations write.
+// It returns why the torrent cannot be applied, or "" if it can.
+func (m *Monitor) applyPreflight(torrent *qbit.Torrent, ops []files.PlannedOp) string {
+	var sources []files.ResolvedFile
+	for i := range ops {
+		sources = append(sources, opSources(&ops[i])...)
+	}
+	m.refreshContentPath(torrent)
+	if err := m.waitForSources(torrent, sources); err != nil {
+		return fmt.Sprintf("source files not ready: %v", err)
+	}
+
+	shortages, err := files.CheckSpace(&m.config.Monitor, ops)
+	if err != nil {
+		m.logger.Printf("Failed to check free space for torrent '%s', continuing: %v", torrent.Name, err)
+		return ""
+	}
+	if len(shortages) > 0 {
+		shortage := shortages[0]
+		return fmt.Sprintf("not enough space on %s: needs %s, %s available (reserve: %s)", shortage.Path,
+			files.FormatSize(shortage.Needed), files.FormatSize(shortage.Available), files.FormatSize(m.config.Monitor.DiskReserve))
+	}
+	return ""
+}
+
+// opSources returns the torrent files an operation reads
+func opSources(op *files.PlannedOp) []files.ResolvedFile {
+	if op.File != nil {
+		return []files.ResolvedFile{*op.File}
+	}
+	if op.Archive != nil {
+		return op.Archive.Volumes
+	}
+	return nil
+}
+
+// displayDecision names an empty conflict decision for drift messages
+func displayDecision(decision string) string {
+	if decision == "" {
+		return "extract"
+	}
+	return decision
+}
@@ -0,0 +1,159 @@
+package worker
+
+import (
+	"fmt"
+	"time"
+
+	"qb-sync/internal/qbit"
+	"qb-sync/internal/state"
+	"qb-sync/internal/tracker"
+)
+
+// handleBrokenTorrents tries to recover torrents in an error state and quarantines
+// those that stay broken for longer than the configured grace period or break again
+// after the configured number of recovery attempts.
+//
+// Entries outlive a recovery attempt, so a torrent that goes through checking and
+// breaks again counts as a failed recovery instead of a first sighting. They are kept
+// in the state directory, so one-shot runs from cron quarantine torrents too.
+// Removing the quarantine tag from a torrent that is still broken starts over.
+func (m *Monitor) handleBrokenTorrents(torrents []qbit.Torrent) {
+	if m.broken == nil {
+		broken, err := m.state.BrokenTorrents()
+		if err != nil {
+			m.logger.Printf("Failed to read the state of broken torrents: %v", err)
+			broken = make(map[string]*state.BrokenTorrent)
+		}
+		m.broken = broken
+	}
+	defer m.saveBrokenTorrents()
+
+	now := time.Now()
+	grace := m.config.Monitor.BrokenGracePeriod
+
+	// Forget torrents that disappeared, or stayed healthy for the grace period after the last attempt
+	present := make(map[string]*qbit.Torrent, len(torrents))
+	for i := range torrents {
+		present[torrents[i].Hash] = &torrents[i]
+	}
+	for hash, entry := range m.broken {
+		torrent, ok := present[hash]
+		if !ok || (!torrent.State.IsError() && !torrent.State.IsTransitional() && now.Sub(entry.LastAttempt) >= grace) {
+			delete(m.broken, hash)
+		}
+	}
+
+	for i := range torrents {
+		torrent := &torrents[i]
+		if torrent.Category != m.config.Monitor.Category {
+			continue
+		}
+		entry, seen := m.broken[torrent.Hash]
+		if !torrent.State.IsError() {
+			if seen {
+				entry.Broken = false
+			}
+			continue
+		}
+		if torrent.HasTag(m.config.Monitor.QuarantineTag) {
+			continue
+		}
+		// Removing the tag asks for another try, so recovery and escalation start over
+		// as for a new sighting. A dry run only pretends to tag, so there is no tag to remove.
+		if seen && entry.Quarantined && !m.config.Monitor.DryRun {
+			m.logger.Printf("Quarantine tag of torrent '%s' was removed, retrying recovery", torrent.Name)
+			seen = false
+		}
+
+		switch {
+		case !seen:
+			entry = &state.BrokenTorrent{FirstSeen: now}
+			m.broken[torrent.Hash] = entry
+			m.logger.Printf("Torrent '%s' is in state '%s'", torrent.Name, torrent.State)
+			m.startRecovery(torrent, entry)
+		case entry.Quarantined:
+			// Quarantined in a dry run, which is reported once
+		case !entry.Broken && entry.Attempts < m.config.Monitor.BrokenMaxAttempts:
+			m.logger.Printf("Torrent '%s' is in state '%s' again after %d recovery attempts", torrent.Name, torrent.State, entry.Attempts)
+			m.startRecovery(torrent, entry)
+		case !entry.Broken || now.Sub(entry.LastAttempt) >= grace:
+			entry.Quarantined = m.quarantineTorrent(torrent, entry)
+		}
+		entry.Broken = true
+	}
+}
+
+// startRecovery starts a recovery cycle of a broken torrent
+func (m *Monitor) startRecovery(torrent *qbit.Torrent, entry *state.BrokenTorrent) {
+	entry.Attempts++
+	entry.LastAttempt = time.Now()
+	m.tryRecoverTorrent(torrent)
+}
+
+// saveBrokenTorrents keeps the state of broken torrents for the next run.
+// A dry run pretends to quarantine, so its state is not kept.
+func (m *Monitor) saveBrokenTorrents() {
+	if m.config.Monitor.DryRun {
+		return
+	}
+	if err := m.state.SaveBrokenTorrents(m.broken); err != nil {
+		m.logger.Printf("Failed to save the state of broken torrents: %v", err)
+	}
+}
+
+// tryRecoverTorrent triggers the configured recovery action for a broken torrent
+func (m *Monitor) tryRecoverTorrent(torrent *qbit.Torrent) {
+	action := m.config.Monitor.BrokenAction
+	if action == "none" {
+		return
+	}
+
+	if m.config.Monitor.DryRun {
+		m.logger.Printf("[DRY RUN] Would %s torrent '%s'", action, torrent.Name)
+		return
+	}
+
+	var err error
+	switch action {
+	case "recheck":
+		// qBittorrent leaves an errored torrent stopped after the recheck, so it would never
+		// download the pieces the recheck found missing
+		if err = m.client.RecheckTorrents(m.ctx, torrent.Hash); err == nil {
+			err = m.client.ResumeTorrents(m.ctx, torrent.Hash)
+		}
+	case "reannounce":
+		err = m.client.ReannounceTorrents(m.ctx, torrent.Hash)
+	}
+	if err != nil {
+		m.logger.Printf("Failed to %s torrent '%s': %v", action, torrent.Name, err)
+		return
+	}
+
+	m.logger.Printf("Triggered %s for torrent '%s', waiting up to %v for recovery", action, torrent.Name, m.config.Monitor.BrokenGracePeriod)
+}
+
+// quarantineTorrent tags a torrent that did not recover and alerts via Telegram.
+// It reports whether the torrent is now considered quarantined.
+func (m *Monitor) quarantineTorrent(torrent *qbit.Torrent, entry *state.BrokenTorrent) bool {
+	tag := m.config.Monitor.QuarantineTag
+
+	if m.config.Monitor.DryRun {
+		m.logger.Printf("[DRY RUN] Would quarantine torrent '%s' with tag '%s'", torrent.Name, tag)
+		return true
+	}
+
+	m.logger.Printf("Torrent '%s' still in state '%s' after %d recovery attempts since %s, quarantining with tag '%s'",
+		torrent.Name, torrent.State, entry.Attempts, entry.FirstSeen.Format("2006-01-02 15:04:05"), tag)
+
+	if err := m.client.AddTags(m.ctx, []string{torrent.Hash}, tag); err != nil {
+		m.logger.Printf("Failed to tag torrent '%s': %v", torrent.Name, err)
+		return false
+	}
+	m.tracker.Seen(torrent)
+	m.tracker.SetState(torrent.Hash, tracker.StateQuarantined, fmt.Sprintf("stayed in state '%s', tagged %s", torrent.State, tag))
+
+	if m.telegramBot != nil && m.telegramBot.IsEnabled() {
+		m.telegramBot.SendBrokenTorrentAlert(torrent.Name, torrent.State, tag)
+	}
+	return true
+}
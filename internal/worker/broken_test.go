@@ -0,0 +1,86 @@
+package worker
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"qb-sync/internal/config"
+	"qb-sync/internal/qbit"
+)
+
+func TestQuarantineTagRemovedWhileBroken(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		mu.Lock()
+		requests = append(requests, r.URL.Path)
+		mu.Unlock()
+	}))
+	defer server.Close()
+	taken := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		taken := requests
+		requests = nil
+		return taken
+	}
+
+	cfg := &config.Config{
+		QB: config.QBConfig{BaseURL: server.URL},
+		Monitor: config.MonitorConfig{
+			Category:          "movies",
+			DestPath:          t.TempDir(),
+			StateDir:          t.TempDir(),
+			BrokenAction:      "recheck",
+			BrokenGracePeriod: time.Hour,
+			BrokenMaxAttempts: 1,
+			QuarantineTag:     "quarantine",
+			UID:               -1,
+			GID:               -1,
+			Umask:             -1,
+		},
+	}
+	m, err := NewMonitor(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetLogOutput(io.Discard)
+
+	torrent := qbit.Torrent{Hash: "abc", Name: "Movie", Category: "movies", State: qbit.StateError}
+	cycle := func(state qbit.State, tags string) []string {
+		torrent.State, torrent.Tags = state, tags
+		m.handleBrokenTorrents([]qbit.Torrent{torrent})
+		return taken()
+	}
+
+	if got := cycle(qbit.StateError, ""); len(got) != 2 || got[0] != "/api/v2/torrents/recheck" {
+		t.Fatalf("first sighting: requests = %v, want recheck and resume", got)
+	}
+	cycle(qbit.StateCheckingDL, "")
+	if got := cycle(qbit.StateError, ""); len(got) != 1 || got[0] != "/api/v2/torrents/addTags" {
+		t.Fatalf("broken again after the last attempt: requests = %v, want addTags", got)
+	}
+	if got := cycle(qbit.StateError, "quarantine"); len(got) != 0 {
+		t.Fatalf("tagged: requests = %v, want none", got)
+	}
+
+	// The user removes the tag while the torrent is still broken
+	if got := cycle(qbit.StateError, ""); len(got) != 2 || got[0] != "/api/v2/torrents/recheck" {
+		t.Fatalf("tag removed: requests = %v, want recheck and resume", got)
+	}
+	entry := m.broken["abc"]
+	if entry.Quarantined || entry.Attempts != 1 {
+		t.Fatalf("tag removed: entry = %+v, want a fresh recovery attempt", *entry)
+	}
+
+	// Escalation runs again and tags the torrent once more
+	cycle(qbit.StateCheckingDL, "")
+	if got := cycle(qbit.StateError, ""); len(got) != 1 || got[0] != "/api/v2/torrents/addTags" {
+		t.Fatalf("broken again after the retry: requests = %v, want addTags", got)
+	}
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"qb-sync/internal/tracker"
+)
+
+// Pause stops processing torrents until Resume is called.
+// A torrent being processed is finished first.
+func (m *Monitor) Pause() {
+	if !m.paused.Swap(true) {
+		m.logger.Printf("Monitor paused")
+	}
+}
+
+// Resume continues processing torrents and syncs immediately
+func (m *Monitor) Resume() {
+	if m.paused.Swap(false) {
+		m.logger.Printf("Monitor resumed")
+		m.RequestSync("resumed")
+	}
+}
+
+// Paused reports whether the monitor is paused
+func (m *Monitor) Paused() bool {
+	return m.paused.Load()
+}
+
+// LastSync returns when the last sync completed, or the zero time before the first one
+func (m *Monitor) LastSync() time.Time {
+	nanos := m.lastSync.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// Torrents returns the tracked torrents
+func (m *Monitor) Torrents() []tracker.Torrent {
+	return m.tracker.List()
+}
+
+// Torrent returns a tracked torrent
+func (m *Monitor) Torrent(hash string) (tracker.Torrent, bool) {
+	return m.tracker.Get(hash)
+}
+
+// Reprocess clears a skip and processes a torrent in an immediate sync
+func (m *Monitor) Reprocess(hash string) {
+	m.tracker.Unskip(hash)
+	m.Enqueue(hash)
+}
+
+// Skip leaves a torrent alone until it is reprocessed
+func (m *Monitor) Skip(hash string) {
+	m.logger.Printf("Skipping torrent %s on request", hash)
+	m.tracker.Skip(hash)
+}
+
+// RefreshPlex refreshes a directory in Plex, or the destination if dirPath is empty
+func (m *Monitor) RefreshPlex(ctx context.Context, dirPath string) error {
+	if m.plexClient == nil {
+		return fmt.Errorf("Plex integration is not enabled")
+	}
+	if dirPath == "" {
+		dirPath = m.config.Monitor.DestPath
+	}
+	if m.config.Monitor.DryRun {
+		m.logger.Printf("[DRY RUN] Would refresh Plex path: %s", dirPath)
+		return nil
+	}
+	return m.plexClient.RefreshPath(ctx, dirPath)
+}
@@ -7,28 +7,55 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"qb-sync/internal/api"
 	"qb-sync/internal/config"
 	"qb-sync/internal/files"
+	"qb-sync/internal/plan"
 	"qb-sync/internal/plex"
 	"qb-sync/internal/qbit"
+	"qb-sync/internal/rclone"
+	"qb-sync/internal/recycle"
+	"qb-sync/internal/state"
 	"qb-sync/internal/telegram"
+	"qb-sync/internal/tracker"
 )
 
 // Monitor handles the polling and processing of torrents
 type Monitor struct {
 	client       *qbit.Client
 	plexClient   *plex.Client
+	rcloneClient *rclone.Client
+	recycleBin   *recycle.Bin
+	state        *state.Store
 	telegramBot  *telegram.Bot
+	filter       *files.Filter
 	config       *config.Config
 	logger       *log.Logger
 	ctx          context.Context
 	cancel       context.CancelFunc
 	wg           sync.WaitGroup
+	versionOnce  sync.Once // the qBittorrent version is detected once, by whichever entry point runs first
 	backoff      time.Duration
+	broken       map[string]*state.BrokenTorrent // loaded from the state directory on first use
+	lastPurge    time.Time
+	lastOrphans  time.Time
+	orphanCount  int             // orphans in the last report, to avoid repeating identical alerts
+	spaceAlerts  map[string]bool // torrents already reported as deferred for disk space
+	syncRequests chan string     // reasons for syncing before the next poll
+	queueMu      sync.Mutex
+	queued       map[string]bool // torrents to process first in the next sync
+	tracker      *tracker.Tracker
+	paused       atomic.Bool
+	watching     atomic.Bool  // whether filesystem events trigger syncs, so polling is only a safety net
+	lastSync     atomic.Int64 // unix nanoseconds of the last completed sync
+	plan         *plan.Plan   // dry-run plan collected during a sync, nil unless a plan file is configured
 }
 
 // NewMonitor creates a new monitor instance
@@ -39,6 +66,12 @@ func NewMonitor(cfg *config.Config) (*Monitor, error) {
 		return nil, fmt.Errorf("failed to create qBittorrent client: %w", err)
 	}
 
+	// Create file filter
+	filter, err := files.NewFilter(&cfg.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file filter: %w", err)
+	}
+
 	// Create Plex client if enabled
 	var plexClient *plex.Client
 	if cfg.Plex.Enabled {
@@ -48,6 +81,15 @@ func NewMonitor(cfg *config.Config) (*Monitor, error) {
 		}
 	}
 
+	// Create rclone client if enabled
+	var rcloneClient *rclone.Client
+	if cfg.Rclone.Enabled {
+		rcloneClient, err = rclone.NewClient(&cfg.Rclone)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create rclone client: %w", err)
+		}
+	}
+
 	// Create Telegram bot if enabled
 	var telegramBot *telegram.Bot
 	if cfg.Telegram.Enabled {
@@ -64,14 +106,22 @@ func NewMonitor(cfg *config.Config) (*Monitor, error) {
 	logger := log.New(os.Stdout, "[qb-sync] ", log.LstdFlags)
 
 	return &Monitor{
-		client:      client,
-		plexClient:  plexClient,
-		telegramBot: telegramBot,
-		config:      cfg,
-		logger:      logger,
-		ctx:         ctx,
-		cancel:      cancel,
-		backoff:     time.Second, // Initial backoff
+		client:       client,
+		plexClient:   plexClient,
+		rcloneClient: rcloneClient,
+		recycleBin:   recycle.New(&cfg.Monitor),
+		state:        state.New(&cfg.Monitor),
+		telegramBot:  telegramBot,
+		filter:       filter,
+		config:       cfg,
+		logger:       logger,
+		ctx:          ctx,
+		cancel:       cancel,
+		backoff:      time.Second, // Initial backoff
+		spaceAlerts:  make(map[string]bool),
+		syncRequests: make(chan string, 1),
+		queued:       make(map[string]bool),
+		tracker:      tracker.New(),
 	}, nil
 }
 
@@ -84,6 +134,8 @@ func (m *Monitor) Run() {
 	m.logger.Printf("Poll interval: %v", m.config.Monitor.PollInterval)
 	m.logger.Printf("Dry run: %t", m.config.Monitor.DryRun)
 
+	m.detectVersion()
+
 	// Add signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -99,6 +151,21 @@ func (m *Monitor) Run() {
 		}()
 	}
 
+	// Start the HTTP server for webhooks if configured
+	if m.config.HTTP.Listen != "" {
+		server := api.NewServer(m.config, m)
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			if err := server.Start(m.ctx); err != nil {
+				m.logger.Printf("HTTP server error: %v", err)
+			}
+		}()
+	}
+
+	// Filesystem events trigger syncs early, polling continues at a slower rate as a safety net
+	m.startWatcher()
+
 	// Start the monitoring loop in a goroutine
 	m.wg.Add(1)
 	go m.monitorLoop()
@@ -111,6 +178,18 @@ func (m *Monitor) Run() {
 	m.Shutdown()
 }
 
+// detectVersion detects the qBittorrent API version so the client picks compatible endpoints.
+// Every entry point that talks to qBittorrent calls it; only the first call queries the server.
+func (m *Monitor) detectVersion() {
+	m.versionOnce.Do(func() {
+		if version, err := m.client.DetectVersion(m.ctx); err != nil {
+			m.logger.Printf("Failed to detect qBittorrent version, assuming 4.x API: %v", err)
+		} else {
+			m.logger.Printf("qBittorrent version: %s", version)
+		}
+	})
+}
+
 // Shutdown gracefully shuts down the monitor
 func (m *Monitor) Shutdown() {
 	m.logger.Printf("Shutting down monitor...")
@@ -128,28 +207,78 @@ func (m *Monitor) Shutdown() {
 func (m *Monitor) monitorLoop() {
 	defer m.wg.Done()
 
-	ticker := time.NewTicker(m.config.Monitor.PollInterval)
+	interval := m.PollInterval()
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
+		// The interval changes when the filesystem watcher stops or restarts
+		if current := m.PollInterval(); current != interval {
+			interval = current
+			ticker.Reset(interval)
+		}
+
 		select {
 		case <-m.ctx.Done():
 			m.logger.Printf("Context cancelled, stopping monitor loop")
 			return
 		case <-ticker.C:
-			m.logger.Printf("Polling for completed torrents (interval: %v)", m.config.Monitor.PollInterval)
-			if err := m.processCompletedTorrents(); err != nil {
-				m.logger.Printf("Error processing torrents: %v", err)
-				// Increase backoff on error
-				m.backoff = min(m.backoff*2, 2*time.Minute)
-			} else {
-				// Reset backoff on success
-				m.backoff = time.Second
-			}
+			m.logger.Printf("Polling for completed torrents (interval: %v)", interval)
+			m.sync()
+		case reason := <-m.syncRequests:
+			m.logger.Printf("Syncing completed torrents (%s)", reason)
+			m.sync()
 		}
 	}
 }
 
+// RequestSync schedules an immediate sync; requests arriving while one is pending are merged
+func (m *Monitor) RequestSync(reason string) {
+	select {
+	case m.syncRequests <- reason:
+	default:
+	}
+}
+
+// Enqueue schedules a torrent to be processed in an immediate sync, ahead of other torrents
+func (m *Monitor) Enqueue(hash string) {
+	m.queueMu.Lock()
+	m.queued[strings.ToLower(hash)] = true
+	m.queueMu.Unlock()
+	m.RequestSync(fmt.Sprintf("torrent %s queued", hash))
+}
+
+// takeQueued returns and clears the queued torrent hashes
+func (m *Monitor) takeQueued() map[string]bool {
+	m.queueMu.Lock()
+	defer m.queueMu.Unlock()
+	queued := m.queued
+	m.queued = make(map[string]bool)
+	return queued
+}
+
+// sync processes completed torrents and runs the periodic maintenance jobs.
+// It returns the error that prevented processing torrents, if any.
+func (m *Monitor) sync() error {
+	if m.Paused() {
+		m.logger.Printf("Monitor paused, skipping sync")
+		return nil
+	}
+	err := m.processCompletedTorrents()
+	if err != nil {
+		m.logger.Printf("Error processing torrents: %v", err)
+		// Increase backoff on error
+		m.backoff = min(m.backoff*2, 2*time.Minute)
+	} else {
+		// Reset backoff on success
+		m.backoff = time.Second
+	}
+	m.purgeRecycleBin()
+	m.reconcileOrphans()
+	m.lastSync.Store(time.Now().UnixNano())
+	return err
+}
+
 // processCompletedTorrents finds and processes completed torrents
 func (m *Monitor) processCompletedTorrents() error {
 	// Get torrents from qBittorrent
@@ -158,9 +287,36 @@ func (m *Monitor) processCompletedTorrents() error {
 		return fmt.Errorf("failed to list torrents: %w", err)
 	}
 
+	// Dry runs collect what they would do into the plan file
+	m.beginPlan()
+	defer m.writePlan()
+
+	// Forget finished torrents a day after they left qBittorrent
+	present := make(map[string]bool, len(torrents))
+	for _, torrent := range torrents {
+		present[strings.ToLower(torrent.Hash)] = true
+	}
+	m.tracker.Prune(present, time.Now().Add(-24*time.Hour))
+
+	// Try to recover torrents in an error state before they are silently ignored
+	m.handleBrokenTorrents(torrents)
+
 	// Filter for completed torrents in the monitored category
 	completed := qbit.FilterCompletedTorrents(torrents, m.config.Monitor.Category)
 
+	// Queued torrents go first so a webhook does not wait for the rest of the category
+	if queued := m.takeQueued(); len(queued) > 0 {
+		sort.SliceStable(completed, func(i, j int) bool {
+			return queued[strings.ToLower(completed[i].Hash)] && !queued[strings.ToLower(completed[j].Hash)]
+		})
+		for _, torrent := range completed {
+			delete(queued, strings.ToLower(torrent.Hash))
+		}
+		for hash := range queued {
+			m.logger.Printf("Queued torrent %s is not a completed torrent in category '%s'", hash, m.config.Monitor.Category)
+		}
+	}
+
 	if len(completed) == 0 {
 		m.logger.Printf("No completed torrents found in category '%s'", m.config.Monitor.Category)
 		return nil
@@ -170,8 +326,26 @@ func (m *Monitor) processCompletedTorrents() error {
 
 	// Process each torrent
 	for _, torrent := range completed {
+		// Pausing takes effect after the torrent being processed
+		if m.Paused() {
+			m.logger.Printf("Monitor paused, stopping before torrent: %s", torrent.Name)
+			break
+		}
+		m.tracker.Seen(&torrent)
+		if torrent.HasTag(m.config.Monitor.QuarantineTag) {
+			m.logger.Printf("Skipping quarantined torrent: %s", torrent.Name)
+			m.tracker.SetState(torrent.Hash, tracker.StateQuarantined, "tagged "+m.config.Monitor.QuarantineTag)
+			continue
+		}
+		if m.tracker.Skipped(torrent.Hash) {
+			m.logger.Printf("Skipping torrent on request: %s", torrent.Name)
+			continue
+		}
 		m.logger.Printf("Processing torrent: %s", torrent.Name)
-		if err := m.ProcessTorrent(&torrent); err != nil {
+		m.tracker.Start(torrent.Hash)
+		err := m.ProcessTorrent(&torrent)
+		m.tracker.Finish(torrent.Hash, err)
+		if err != nil {
 			m.logger.Printf("Error processing torrent '%s': %v", torrent.Name, err)
 		} else {
 			m.logger.Printf("Successfully processed torrent: %s", torrent.Name)
@@ -184,11 +358,14 @@ func (m *Monitor) processCompletedTorrents() error {
 // ProcessTorrent processes a single completed torrent
 func (m *Monitor) ProcessTorrent(torrent *qbit.Torrent) error {
 	// Get file list for the torrent
-	torrentFiles, err := m.client.FilesByHash(m.ctx, torrent.Hash)
+	rawFiles, err := m.client.FilesByHash(m.ctx, torrent.Hash)
 	if err != nil {
 		return fmt.Errorf("failed to get file list for torrent '%s': %w", torrent.Name, err)
 	}
 
+	// Resolve where each file lives for the torrent's layout
+	torrentFiles := files.ResolveFiles(&m.config.Monitor, torrent, rawFiles)
+
 	if len(torrentFiles) == 0 {
 		m.logger.Printf("No files found for torrent '%s'", torrent.Name)
 		return nil
@@ -196,59 +373,116 @@ func (m *Monitor) ProcessTorrent(torrent *qbit.Torrent) error {
 
 	m.logger.Printf("Found %d files in torrent '%s'", len(torrentFiles), torrent.Name)
 
-	// Process each file
+	// Drop samples, extras and junk before touching the filesystem
+	torrentFiles, skippedFiles := m.filter.Apply(torrentFiles)
+	for _, skipped := range skippedFiles {
+		m.logger.Printf("Skipping file '%s': %s", skipped.Name, skipped.Reason)
+	}
+	if len(torrentFiles) == 0 {
+		// Nothing to import, so the torrent is done and gets the same actions as an imported one
+		m.logger.Printf("All %d files of torrent '%s' were skipped by filters, nothing to import", len(skippedFiles), torrent.Name)
+	} else {
+		// Make sure the mount actually shows all files before linking them
+		m.refreshContentPath(torrent)
+		if err := m.waitForSources(torrent, torrentFiles); err != nil {
+			return fmt.Errorf("source files for torrent '%s' not ready: %w", torrent.Name, err)
+		}
+	}
+
+	// Plan how each file is imported
+	plan, err := files.BuildPlan(&m.config.Monitor, torrent, torrentFiles)
+	if err != nil {
+		return fmt.Errorf("failed to plan file operations for torrent '%s': %w", torrent.Name, err)
+	}
+
+	// Copies and extractions must fit on the destination before anything is written
+	if m.deferForDiskSpace(torrent, plan) {
+		return nil
+	}
+
+	// Process each planned operation
 	var processedCount int
 	var allSuccess = true
+	var destPaths []string
+	decisions := make(map[string]int)
+	var operations []*files.FileOperation
+	defer func() { m.tracker.SetFiles(torrent.Hash, operations) }()
 
-	for _, file := range torrentFiles {
-		op, err := files.LinkOrCopy(&m.config.Monitor, torrent, &file)
-		if err != nil {
-			if !m.config.Monitor.DryRun {
-				m.logger.Printf("Error preparing file operation for '%s': %v", file.Name, err)
-			}
-			allSuccess = false
-			continue
-		}
+	for i := range plan {
+		op := &plan[i]
 
-		// Skip if destination already exists and has correct size
 		if m.config.Monitor.DryRun {
-			m.logger.Printf("[DRY RUN] Would %s %s to %s", m.config.Monitor.Operation, op.Source, op.Destination)
-			processedCount++
+			m.logger.Printf("[DRY RUN] Would %s %s to %s", op.Method, op.Source, op.Destination)
+			for _, old := range op.Replaces {
+				m.logger.Printf("[DRY RUN] Would move replaced %s to the recycle bin", old)
+			}
+			processedCount += op.FileCount
 			continue
 		}
 
-		// The operation has already been performed by LinkOrCopy function
-		if !op.Success {
-			m.logger.Printf("Failed to %s file '%s': %v", m.config.Monitor.Operation, file.Name, op.Error)
+		results, err := files.ExecuteOp(&m.config.Monitor, torrent, op)
+		operations = append(operations, results...)
+		if err != nil && (len(results) == 0 || results[len(results)-1].Success) {
+			// Record failures that happened before a file operation was created
+			operations = append(operations, &files.FileOperation{Source: op.Source, Destination: op.Destination, Method: op.Method, Error: err})
+		}
+		for _, result := range results {
+			if !result.Success {
+				continue
+			}
+			source := result.Source
+			if result.Entry != "" {
+				source = fmt.Sprintf("%s [%s]", result.Source, result.Entry)
+			}
+			decisions[result.Decision]++
+			switch result.Decision {
+			case files.DecisionExists:
+				m.logger.Printf("Already present: %s", result.Destination)
+			case files.DecisionRecycled:
+				m.logger.Printf("Moved replaced %s to the recycle bin at %s", result.Source, result.Destination)
+				continue
+			case files.DecisionSkipped:
+				m.logger.Printf("Kept existing %s instead of %s (conflict policy: %s)", result.Destination, source, m.config.Monitor.ConflictPolicy)
+				continue
+			default:
+				m.logger.Printf("Successfully %s %s to %s (%s)", result.Method, source, result.Destination, result.Decision)
+			}
+			destPaths = append(destPaths, result.Destination)
+		}
+		if err != nil {
+			m.logger.Printf("Failed to %s '%s': %v", op.Method, op.Source, err)
 			allSuccess = false
-		} else {
-			m.logger.Printf("Successfully %s %s to %s", m.config.Monitor.Operation, op.Source, op.Destination)
-			processedCount++
+			continue
 		}
+		processedCount += op.FileCount
 	}
 
-	m.logger.Printf("Processed %d/%d files for torrent '%s'", processedCount, len(torrentFiles), torrent.Name)
+	m.recordImports(torrent.Hash, operations)
+	m.logger.Printf("Processed %d/%d files for torrent '%s' (%d skipped by filters%s)", processedCount, len(torrentFiles), torrent.Name, len(skippedFiles), formatDecisions(decisions))
 
 	// If all operations were successful and not in dry run mode, trigger Plex refresh and delete the torrent
 	if !m.config.Monitor.DryRun && (allSuccess || len(torrentFiles) == 0) {
 		// Trigger Plex refresh if enabled and we have processed files
 		if m.config.Plex.Enabled && processedCount > 0 {
-			if err := m.refreshPlexLibraries(torrent, torrentFiles); err != nil {
+			if err := m.refreshPlexLibraries(torrent, destPaths); err != nil {
 				m.logger.Printf("Failed to refresh Plex libraries for torrent '%s': %v", torrent.Name, err)
 			}
 		}
 
-		// Delete torrent if configured
-		if m.config.Monitor.DeleteTorrent {
-			m.logger.Printf("Deleting torrent '%s' from qBittorrent (delete files: %t)", torrent.Name, m.config.Monitor.DeleteFiles)
-			if err := m.client.DeleteTorrent(m.ctx, torrent.Hash, m.config.Monitor.DeleteFiles); err != nil {
-				return fmt.Errorf("failed to delete torrent: %w", err)
+		// Delete torrent if configured. Files the conflict policy did not import only exist
+		// in the torrent's data, so it is kept rather than deleted with its files.
+		if kept := decisions[files.DecisionSkipped]; m.config.Monitor.DeleteTorrent && m.config.Monitor.DeleteFiles && kept > 0 {
+			m.logger.Printf("Keeping torrent '%s' and its files in qBittorrent: %d files were not imported because existing files were kept (conflict policy: %s)",
+				torrent.Name, kept, m.config.Monitor.ConflictPolicy)
+		} else if m.config.Monitor.DeleteTorrent {
+			if err := m.deleteTorrent(torrent, m.config.Monitor.DeleteFiles); err != nil {
+				return err
 			}
-			m.logger.Printf("Successfully deleted torrent '%s' from qBittorrent", torrent.Name)
 		} else {
 			m.logger.Printf("Torrent deletion disabled, keeping '%s' in qBittorrent", torrent.Name)
 		}
 	} else if m.config.Monitor.DryRun {
+		m.recordPlan(torrent, plan, skippedFiles)
 		if m.config.Plex.Enabled && processedCount > 0 {
 			m.logger.Printf("[DRY RUN] Would refresh Plex libraries for torrent '%s'", torrent.Name)
 		}
@@ -258,8 +492,22 @@ func (m *Monitor) ProcessTorrent(torrent *qbit.Torrent) error {
 	return nil
 }
 
-// refreshPlexLibraries refreshes Plex libraries that might contain the torrent files
-func (m *Monitor) refreshPlexLibraries(torrent *qbit.Torrent, torrentFiles []qbit.TorrentFile) error {
+// deleteTorrent removes an imported torrent from qBittorrent
+func (m *Monitor) deleteTorrent(torrent *qbit.Torrent, deleteFiles bool) error {
+	m.logger.Printf("Deleting torrent '%s' from qBittorrent (delete files: %t)", torrent.Name, deleteFiles)
+	contentInfo, statErr := os.Stat(files.LocalPath(&m.config.Monitor, torrent.ContentPath))
+	if err := m.client.DeleteTorrent(m.ctx, torrent.Hash, deleteFiles); err != nil {
+		return fmt.Errorf("failed to delete torrent: %w", err)
+	}
+	m.logger.Printf("Successfully deleted torrent '%s' from qBittorrent", torrent.Name)
+
+	// Drop the deleted content from the rclone directory cache
+	m.forgetContentPath(torrent, statErr == nil && contentInfo.IsDir())
+	return nil
+}
+
+// refreshPlexLibraries refreshes Plex libraries that might contain the imported files
+func (m *Monitor) refreshPlexLibraries(torrent *qbit.Torrent, destPaths []string) error {
 	if m.plexClient == nil {
 		return fmt.Errorf("Plex client not initialized")
 	}
@@ -270,14 +518,7 @@ func (m *Monitor) refreshPlexLibraries(torrent *qbit.Torrent, torrentFiles []qbi
 	refreshedPaths := make(map[string]bool)
 	refreshSuccess := false
 
-	for _, file := range torrentFiles {
-		// Build the destination path for this file
-		destPath, err := files.BuildDestPath(&m.config.Monitor, torrent, &file)
-		if err != nil {
-			m.logger.Printf("Failed to build destination path for file '%s': %v", file.Name, err)
-			continue
-		}
-
+	for _, destPath := range destPaths {
 		// Get the directory containing the file
 		dirPath := filepath.Dir(destPath)
 
@@ -308,24 +549,21 @@ func (m *Monitor) refreshPlexLibraries(torrent *qbit.Torrent, torrentFiles []qbi
 	return nil
 }
 
-// isTransitionalState checks if a torrent is in a transitional state
-func isTransitionalState(state string) bool {
-	transitionalStates := []string{
-		"checkingDL", "checkingUP", "checkingResumeData",
-		"moving", "metaDL", "allocating",
-	}
-	for _, s := range transitionalStates {
-		if state == s {
-			return true
-		}
-	}
-	return false
-}
-
 // min returns the minimum of two durations
 func min(a, b time.Duration) time.Duration {
 	if a < b {
 		return a
 	}
 	return b
-}
\ No newline at end of file
+}
+
+// formatDecisions summarizes conflict decisions for the processing log, e.g. ", created 3, replaced 1"
+func formatDecisions(decisions map[string]int) string {
+	var summary strings.Builder
+	for _, decision := range []string{files.DecisionCreated, files.DecisionExists, files.DecisionReplaced, files.DecisionKeptBoth, files.DecisionSkipped, files.DecisionRecycled} {
+		if count := decisions[decision]; count > 0 {
+			fmt.Fprintf(&summary, ", %s %d", decision, count)
+		}
+	}
+	return summary.String()
+}
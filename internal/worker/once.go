@@ -0,0 +1,34 @@
+package worker
+
+import (
+	"qb-sync/internal/tracker"
+)
+
+// Summary describes the outcome of a single sync
+type Summary struct {
+	Torrents []tracker.Torrent // torrents handled in the sync, ordered by name
+	States   map[tracker.State]int
+}
+
+// Failed returns the number of torrents that failed
+func (s *Summary) Failed() int {
+	return s.States[tracker.StateFailed]
+}
+
+// RunOnce performs a single sync and returns what happened to each torrent.
+// All file operations, Plex refreshes and deletions are finished when it returns.
+func (m *Monitor) RunOnce() (*Summary, error) {
+	m.logger.Printf("Running a single sync for category: %s", m.config.Monitor.Category)
+	m.detectVersion()
+
+	err := m.sync()
+
+	summary := &Summary{
+		Torrents: m.tracker.List(),
+		States:   make(map[tracker.State]int),
+	}
+	for _, torrent := range summary.Torrents {
+		summary.States[torrent.State]++
+	}
+	return summary, err
+}
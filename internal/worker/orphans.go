@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"qb-sync/internal/files"
+)
+
+// reconcileOrphans scans the destination for orphans at the configured interval,
+// reports them and moves them to the recycle bin if enabled
+func (m *Monitor) reconcileOrphans() {
+	interval := m.config.Monitor.OrphanInterval
+	if interval <= 0 || time.Since(m.lastOrphans) < interval {
+		return
+	}
+	m.lastOrphans = time.Now()
+
+	var err error
+
+	// Files with a link count of 1 are only orphans if qb-sync imported them from a torrent that is gone
+	var imports map[string]string
+	var torrents map[string]bool
+	if m.config.Monitor.OrphanHardlinks {
+		if imports, err = m.state.Imports(); err != nil {
+			m.logger.Printf("Failed to read the record of imported files: %v", err)
+			return
+		}
+		list, err := m.client.ListAllTorrents(m.ctx)
+		if err != nil {
+			m.logger.Printf("Failed to list torrents for the orphan scan: %v", err)
+			return
+		}
+		torrents = make(map[string]bool, len(list))
+		for _, torrent := range list {
+			torrents[strings.ToLower(torrent.Hash)] = true
+		}
+		defer m.forgetMissingImports(imports)
+	}
+
+	report, err := files.FindOrphans(&m.config.Monitor, imports, torrents)
+	if err != nil {
+		m.logger.Printf("Failed to scan destination for orphans: %v", err)
+		return
+	}
+	if report.Count() == 0 {
+		m.logger.Printf("No orphans found in %s", m.config.Monitor.DestPath)
+		m.orphanCount = 0
+		return
+	}
+
+	for _, path := range report.DanglingSymlinks {
+		m.logger.Printf("Orphan: dangling symlink %s", path)
+	}
+	for _, path := range report.UnlinkedFiles {
+		m.logger.Printf("Orphan: file without torrent data %s", path)
+	}
+	for _, path := range report.EmptyDirs {
+		m.logger.Printf("Orphan: empty directory %s", path)
+	}
+
+	var outcome string
+	removed := 0
+	switch {
+	case report.MountSuspect():
+		outcome = fmt.Sprintf("%d of %d symlinks are dangling. The source mount is probably unavailable, nothing was removed.", len(report.DanglingSymlinks), report.Symlinks)
+	case !m.config.Monitor.OrphanRemove:
+		outcome = "Removal is disabled, nothing was removed."
+	case m.config.Monitor.DryRun:
+		outcome = "Dry run, nothing was removed."
+	default:
+		removed, err = files.RemoveOrphans(&m.config.Monitor, report)
+		if err != nil {
+			m.logger.Printf("Failed to remove orphans: %v", err)
+		}
+		outcome = fmt.Sprintf("Removed %d entries, files were moved to the recycle bin.", removed)
+	}
+	m.logger.Printf("Found %d orphans in %s: %s", report.Count(), m.config.Monitor.DestPath, outcome)
+
+	// Unchanged reports are only logged
+	unchanged := removed == 0 && report.Count() == m.orphanCount
+	m.orphanCount = report.Count() - removed
+	if m.telegramBot != nil && !unchanged {
+		m.telegramBot.SendOrphanReport(len(report.DanglingSymlinks), len(report.UnlinkedFiles), len(report.EmptyDirs), outcome)
+	}
+}
+
+// recordImports remembers the files written for a torrent, so the orphan scan can tell
+// them apart from media qb-sync did not import
+func (m *Monitor) recordImports(hash string, operations []*files.FileOperation) {
+	if !m.config.Monitor.OrphanHardlinks || m.config.Monitor.DryRun {
+		return
+	}
+	var destinations []string
+	for _, op := range operations {
+		if !op.Success || op.Entry != "" {
+			continue
+		}
+		switch op.Decision {
+		case files.DecisionCreated, files.DecisionReplaced, files.DecisionKeptBoth:
+			destinations = append(destinations, op.Destination)
+		}
+	}
+	if err := m.state.AddImports(hash, destinations); err != nil {
+		m.logger.Printf("Failed to record imported files: %v", err)
+	}
+}
+
+// forgetMissingImports drops recorded imports whose file no longer exists
+func (m *Monitor) forgetMissingImports(imports map[string]string) {
+	var missing []string
+	for path := range imports {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			missing = append(missing, path)
+		}
+	}
+	if err := m.state.ForgetImports(missing); err != nil {
+		m.logger.Printf("Failed to update the record of imported files: %v", err)
+	}
+}
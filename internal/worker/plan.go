@@ -0,0 +1,115 @@
+package worker
+
+import (
+	"path/filepath"
+	"time"
+
+	"qb-sync/internal/files"
+	"qb-sync/internal/plan"
+	"qb-sync/internal/qbit"
+)
+
+// beginPlan starts collecting the plan of a dry-run sync if a plan file is configured
+func (m *Monitor) beginPlan() {
+	if !m.config.Monitor.DryRun || m.config.Monitor.PlanFile == "" {
+		m.plan = nil
+		return
+	}
+	m.plan = &plan.Plan{
+		Version:     plan.Version,
+		CreatedAt:   time.Now().UTC(),
+		Category:    m.config.Monitor.Category,
+		Destination: m.config.Monitor.DestPath,
+		Operation:   m.config.Monitor.Operation,
+		Conflict:    m.config.Monitor.ConflictPolicy,
+		Torrents:    []plan.Torrent{},
+	}
+}
+
+// writePlan writes the collected plan to the plan file
+func (m *Monitor) writePlan() {
+	if m.plan == nil {
+		return
+	}
+	defer func() { m.plan = nil }()
+
+	m.plan.Sort()
+	format, err := plan.FormatFor(m.config.Monitor.PlanFile, m.config.Monitor.PlanFormat)
+	if err == nil {
+		err = plan.WriteFile(m.config.Monitor.PlanFile, format, m.plan)
+	}
+	if err != nil {
+		m.logger.Printf("Failed to write plan to %s: %v", m.config.Monitor.PlanFile, err)
+		return
+	}
+	m.logger.Printf("[DRY RUN] Wrote plan for %d torrents to %s", len(m.plan.Torrents), m.config.Monitor.PlanFile)
+}
+
+// recordPlan adds the planned import of a torrent to the plan of the current sync
+func (m *Monitor) recordPlan(torrent *qbit.Torrent, ops []files.PlannedOp, skipped []files.SkippedFile) {
+	if m.plan == nil {
+		return
+	}
+
+	planned := plan.Torrent{
+		Hash:        torrent.Hash,
+		Name:        torrent.Name,
+		Category:    torrent.Category,
+		ContentPath: torrent.ContentPath,
+		Steps:       []plan.Step{},
+	}
+	for _, file := range skipped {
+		planned.Filtered = append(planned.Filtered, plan.Skipped{Name: file.Name, Reason: file.Reason})
+	}
+
+	refresh := make(map[string]bool)
+	for i := range ops {
+		op := &ops[i]
+		step := plan.Step{
+			Method:      op.Method,
+			Source:      op.Source,
+			Destination: op.Destination,
+			Replaces:    op.Replaces,
+		}
+		refreshDir := op.Destination
+		if op.File != nil {
+			step.Size = op.File.Size
+			refreshDir = filepath.Dir(op.Destination)
+		} else if op.Archive != nil {
+			step.Size = op.Archive.Size()
+		}
+
+		destination, decision, err := files.PreviewConflict(&m.config.Monitor, torrent, op)
+		step.Destination, step.Decision = destination, decision
+		if err != nil {
+			step.Error = err.Error()
+		}
+		planned.Steps = append(planned.Steps, step)
+
+		if m.config.Plex.Enabled && err == nil && decision != files.DecisionSkipped && !refresh[refreshDir] {
+			refresh[refreshDir] = true
+			planned.PlexRefresh = append(planned.PlexRefresh, refreshDir)
+		}
+	}
+
+	if shortages, err := files.CheckSpace(&m.config.Monitor, ops); err == nil {
+		for _, shortage := range shortages {
+			planned.Warnings = append(planned.Warnings, "not enough space on "+shortage.Path)
+		}
+	}
+
+	// Deleting the files would lose those the conflict policy does not import
+	keepsFiles := false
+	for _, step := range planned.Steps {
+		if step.Decision == files.DecisionSkipped {
+			keepsFiles = true
+		}
+	}
+	if m.config.Monitor.DeleteTorrent && !(m.config.Monitor.DeleteFiles && keepsFiles) {
+		planned.Actions = append(planned.Actions, plan.Action{Kind: plan.ActionDeleteTorrent, DeleteFiles: m.config.Monitor.DeleteFiles})
+	} else {
+		planned.Actions = append(planned.Actions, plan.Action{Kind: plan.ActionKeepTorrent})
+	}
+
+	m.plan.Torrents = append(m.plan.Torrents, planned)
+}
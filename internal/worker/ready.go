@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"qb-sync/internal/files"
+	"qb-sync/internal/qbit"
+)
+
+// waitForSources waits until every source file of a torrent is visible on the mount
+// with its expected size, refreshing the rclone VFS cache for missing files if enabled
+func (m *Monitor) waitForSources(torrent *qbit.Torrent, torrentFiles []files.ResolvedFile) error {
+	deadline := time.Now().Add(m.config.Monitor.ReadyTimeout)
+
+	for {
+		missing := files.CheckSourcesReady(torrentFiles)
+		if len(missing) == 0 {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d source files not ready after %v (first: %s: %v)",
+				len(missing), m.config.Monitor.ReadyTimeout, missing[0].Path, missing[0].Error)
+		}
+
+		m.logger.Printf("Waiting for %d source files of torrent '%s' to appear (first: %s: %v)",
+			len(missing), torrent.Name, missing[0].Path, missing[0].Error)
+
+		m.refreshMissingSources(missing)
+
+		select {
+		case <-m.ctx.Done():
+			return m.ctx.Err()
+		case <-time.After(m.config.Monitor.ReadyInterval):
+		}
+	}
+}
+
+// refreshMissingSources asks rclone to refresh the parent directories of missing files
+func (m *Monitor) refreshMissingSources(missing []files.MissingSource) {
+	if m.rcloneClient == nil {
+		return
+	}
+
+	refreshed := make(map[string]bool)
+	for _, source := range missing {
+		dir := filepath.Dir(source.Path)
+		if refreshed[dir] {
+			continue
+		}
+		refreshed[dir] = true
+
+		if err := m.rcloneClient.RefreshDir(m.ctx, dir, false); err != nil {
+			m.logger.Printf("Failed to refresh rclone directory '%s': %v", dir, err)
+		}
+	}
+}
+
+// refreshContentPath refreshes the rclone VFS cache for a torrent's content before processing
+func (m *Monitor) refreshContentPath(torrent *qbit.Torrent) {
+	if m.rcloneClient == nil || torrent.ContentPath == "" {
+		return
+	}
+
+	// Refresh a content directory recursively; for single files or content that is
+	// not visible yet, refresh the parent so the entry itself shows up
+	contentPath := files.LocalPath(&m.config.Monitor, torrent.ContentPath)
+	dir, recursive := filepath.Dir(contentPath), false
+	if info, err := os.Stat(contentPath); err == nil && info.IsDir() {
+		dir, recursive = contentPath, true
+	}
+
+	if err := m.rcloneClient.RefreshDir(m.ctx, dir, recursive); err != nil {
+		m.logger.Printf("Failed to refresh rclone directory '%s' for torrent '%s': %v", dir, torrent.Name, err)
+	}
+}
+
+// forgetContentPath drops a deleted torrent's content from the rclone VFS cache
+func (m *Monitor) forgetContentPath(torrent *qbit.Torrent, isDir bool) {
+	if m.rcloneClient == nil || torrent.ContentPath == "" {
+		return
+	}
+
+	contentPath := files.LocalPath(&m.config.Monitor, torrent.ContentPath)
+	if err := m.rcloneClient.Forget(m.ctx, contentPath, isDir); err != nil {
+		m.logger.Printf("Failed to forget rclone path '%s' for torrent '%s': %v", contentPath, torrent.Name, err)
+	}
+}
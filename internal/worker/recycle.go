@@ -0,0 +1,28 @@
+package worker
+
+import "time"
+
+// purgeInterval is how often expired recycle bin directories are looked for
+const purgeInterval = time.Hour
+
+// purgeRecycleBin removes recycle bin directories older than the retention period
+func (m *Monitor) purgeRecycleBin() {
+	if m.config.Monitor.RecycleRetention <= 0 || time.Since(m.lastPurge) < purgeInterval {
+		return
+	}
+	m.lastPurge = time.Now()
+
+	if m.config.Monitor.DryRun {
+		m.logger.Printf("[DRY RUN] Would purge recycle bin entries older than %v", m.config.Monitor.RecycleRetention)
+		return
+	}
+
+	purged, err := m.recycleBin.Purge(time.Now())
+	if err != nil {
+		m.logger.Printf("Failed to purge recycle bin: %v", err)
+		return
+	}
+	if purged > 0 {
+		m.logger.Printf("Purged %d expired recycle bin directories from %s", purged, m.recycleBin.Dir())
+	}
+}
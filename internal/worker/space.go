@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"fmt"
+
+	"qb-sync/internal/files"
+	"qb-sync/internal/qbit"
+	"qb-sync/internal/tracker"
+)
+
+// deferForDiskSpace checks that the destination can hold what a plan writes.
+// It returns true if the torrent must wait for free space; it is retried on the next poll.
+func (m *Monitor) deferForDiskSpace(torrent *qbit.Torrent, plan []files.PlannedOp) bool {
+	shortages, err := files.CheckSpace(&m.config.Monitor, plan)
+	if err != nil {
+		m.logger.Printf("Failed to check free space for torrent '%s', continuing: %v", torrent.Name, err)
+		return false
+	}
+	if len(shortages) == 0 {
+		delete(m.spaceAlerts, torrent.Hash)
+		return false
+	}
+
+	for _, shortage := range shortages {
+		m.logger.Printf("Not enough space on %s for torrent '%s': needs %s, %s available (reserve: %s)",
+			shortage.Path, torrent.Name, files.FormatSize(shortage.Needed), files.FormatSize(shortage.Available), files.FormatSize(m.config.Monitor.DiskReserve))
+	}
+	if m.config.Monitor.DryRun {
+		m.logger.Printf("[DRY RUN] Would defer torrent '%s' until enough space is available", torrent.Name)
+		return false
+	}
+
+	m.logger.Printf("Deferring torrent '%s' until enough space is available", torrent.Name)
+	m.tracker.SetState(torrent.Hash, tracker.StateDeferred, fmt.Sprintf("not enough space on %s: needs %s, %s available",
+		shortages[0].Path, files.FormatSize(shortages[0].Needed), files.FormatSize(shortages[0].Available)))
+	if m.telegramBot != nil && !m.spaceAlerts[torrent.Hash] {
+		shortage := shortages[0]
+		m.telegramBot.SendDiskSpaceAlert(torrent.Name, shortage.Path, shortage.Needed, shortage.Available)
+	}
+	m.spaceAlerts[torrent.Hash] = true
+	return true
+}
@@ -0,0 +1,140 @@
+package worker
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"qb-sync/internal/files"
+	"qb-sync/internal/qbit"
+	"qb-sync/internal/tracker"
+)
+
+// Import states reported by ListTorrents
+const (
+	ImportIncomplete  = "incomplete"   // still downloading or in an error state
+	ImportQuarantined = "quarantined"  // tagged with the quarantine tag
+	ImportFiltered    = "filtered"     // every file is excluded by the filters
+	ImportNone        = "not imported" // no planned destination exists
+	ImportPartial     = "partial"      // some planned destinations exist
+	ImportDone        = "imported"     // all planned destinations exist
+)
+
+// TorrentStatus is a torrent in qBittorrent with the state of its import
+type TorrentStatus struct {
+	Hash     string     `json:"hash"`
+	Name     string     `json:"name"`
+	Category string     `json:"category"`
+	State    qbit.State `json:"state"`
+	Progress float64    `json:"progress"`
+	Import   string     `json:"import"`
+	Planned  int        `json:"planned"`  // planned operations
+	Imported int        `json:"imported"` // planned operations whose destination exists
+	Error    string     `json:"error,omitempty"`
+}
+
+// SetLogOutput redirects the monitor log, e.g. to keep stdout free for command output
+func (m *Monitor) SetLogOutput(w io.Writer) {
+	m.logger.SetOutput(w)
+}
+
+// ListTorrents returns the torrents of the monitored category, or of all categories,
+// with their import state derived from the destinations their plan would write
+func (m *Monitor) ListTorrents(allCategories bool) ([]TorrentStatus, error) {
+	m.detectVersion()
+	torrents, err := m.client.ListAllTorrents(m.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list torrents: %w", err)
+	}
+
+	var statuses []TorrentStatus
+	for i := range torrents {
+		torrent := &torrents[i]
+		if !allCategories && torrent.Category != m.config.Monitor.Category {
+			continue
+		}
+		status := TorrentStatus{
+			Hash:     torrent.Hash,
+			Name:     torrent.Name,
+			Category: torrent.Category,
+			State:    torrent.State,
+			Progress: torrent.Progress,
+		}
+
+		switch {
+		case len(qbit.FilterCompletedTorrents([]qbit.Torrent{*torrent}, "")) == 0:
+			status.Import = ImportIncomplete
+		case torrent.HasTag(m.config.Monitor.QuarantineTag):
+			status.Import = ImportQuarantined
+		default:
+			plan, err := m.planTorrent(torrent)
+			if err != nil {
+				status.Error = err.Error()
+				break
+			}
+			status.Planned = len(plan)
+			for _, op := range plan {
+				if _, err := os.Lstat(op.Destination); err == nil {
+					status.Imported++
+				}
+			}
+			switch {
+			case status.Planned == 0:
+				status.Import = ImportFiltered
+			case status.Imported == status.Planned:
+				status.Import = ImportDone
+			case status.Imported > 0:
+				status.Import = ImportPartial
+			default:
+				status.Import = ImportNone
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// ProcessHash imports a single completed torrent regardless of its category
+func (m *Monitor) ProcessHash(hash string) (tracker.Torrent, error) {
+	m.detectVersion()
+	torrents, err := m.client.ListAllTorrents(m.ctx)
+	if err != nil {
+		return tracker.Torrent{}, fmt.Errorf("failed to list torrents: %w", err)
+	}
+
+	for i := range torrents {
+		torrent := &torrents[i]
+		if !strings.EqualFold(torrent.Hash, hash) {
+			continue
+		}
+		if len(qbit.FilterCompletedTorrents([]qbit.Torrent{*torrent}, "")) == 0 {
+			return tracker.Torrent{}, fmt.Errorf("torrent '%s' is not complete (state: %s, progress: %.1f%%)",
+				torrent.Name, torrent.State.DisplayName(), torrent.Progress*100)
+		}
+
+		m.beginPlan()
+		defer m.writePlan()
+
+		m.tracker.Seen(torrent)
+		m.tracker.Start(torrent.Hash)
+		err := m.ProcessTorrent(torrent)
+		m.tracker.Finish(torrent.Hash, err)
+		result, _ := m.tracker.Get(torrent.Hash)
+		return result, err
+	}
+	return tracker.Torrent{}, fmt.Errorf("torrent %s not found", hash)
+}
+
+// planTorrent plans the import of a torrent without waiting for its source files
+func (m *Monitor) planTorrent(torrent *qbit.Torrent) ([]files.PlannedOp, error) {
+	rawFiles, err := m.client.FilesByHash(m.ctx, torrent.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file list: %w", err)
+	}
+	torrentFiles, _ := m.filter.Apply(files.ResolveFiles(&m.config.Monitor, torrent, rawFiles))
+	if len(torrentFiles) == 0 {
+		return nil, nil
+	}
+	return files.BuildPlan(&m.config.Monitor, torrent, torrentFiles)
+}
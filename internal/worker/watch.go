@@ -0,0 +1,85 @@
+package worker
+
+import (
+	"time"
+
+	"qb-sync/internal/files"
+	"qb-sync/internal/watch"
+)
+
+// startWatcher watches the save path for completed files if enabled.
+// It returns false if syncing relies on polling alone.
+func (m *Monitor) startWatcher() bool {
+	if !m.config.Monitor.Watch {
+		return false
+	}
+
+	path := m.config.Monitor.WatchPath
+	if path == "" {
+		savePath, err := m.client.DefaultSavePath(m.ctx)
+		if err != nil {
+			m.logger.Printf("Failed to determine the save path to watch, falling back to polling: %v", err)
+			return false
+		}
+		path = files.LocalPath(&m.config.Monitor, savePath)
+	}
+
+	watcher, err := watch.New(path)
+	if err != nil {
+		m.logger.Printf("Failed to watch %s, falling back to polling: %v", path, err)
+		return false
+	}
+	m.watching.Store(true)
+
+	m.wg.Add(1)
+	go m.runWatcher(path, watcher)
+
+	m.logger.Printf("Watching %s for completed files (debounce: %v, safety poll: %v)", path, m.config.Monitor.WatchDebounce, m.config.Monitor.WatchPollInterval)
+	return true
+}
+
+// runWatcher requests syncs on filesystem events until the monitor stops. A watcher that
+// stops, e.g. because the save path was unmounted, is restarted; until then the monitor
+// polls at the regular interval.
+func (m *Monitor) runWatcher(path string, watcher *watch.Watcher) {
+	defer m.wg.Done()
+
+	for {
+		err := watcher.Debounce(m.ctx, m.config.Monitor.WatchDebounce,
+			func() { m.RequestSync("filesystem event") },
+			func(err error) { m.logger.Printf("Filesystem watcher error: %v", err) })
+		watcher.Close()
+		if m.ctx.Err() != nil {
+			return
+		}
+
+		m.watching.Store(false)
+		m.logger.Printf("Filesystem watcher for %s stopped, polling every %v until it is restarted: %v", path, m.config.Monitor.PollInterval, err)
+		m.RequestSync("filesystem watcher stopped")
+
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-time.After(m.config.Monitor.PollInterval):
+			}
+			if watcher, err = watch.New(path); err == nil {
+				break
+			}
+			m.logger.Printf("Failed to watch %s, retrying in %v: %v", path, m.config.Monitor.PollInterval, err)
+		}
+
+		// Events were missed while the watcher was down
+		m.watching.Store(true)
+		m.logger.Printf("Watching %s again", path)
+		m.RequestSync("filesystem watcher restarted")
+	}
+}
+
+// PollInterval returns the interval the monitor loop currently polls at
+func (m *Monitor) PollInterval() time.Duration {
+	if m.watching.Load() {
+		return m.config.Monitor.WatchPollInterval
+	}
+	return m.config.Monitor.PollInterval
+}